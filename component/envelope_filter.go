@@ -1,81 +1,43 @@
 package component
 
 import (
-	"github.com/gotracker/voice"
+	"math"
+
 	"github.com/gotracker/voice/envelope"
 )
 
 // FilterEnvelope is a filter frequency cutoff modulation envelope
 type FilterEnvelope struct {
-	enabled   bool
-	state     envelope.State[int8]
-	value     int8
-	keyOn     bool
-	prevKeyOn bool
+	GenericEnvelope[int8, int8]
 }
 
 // Reset resets the state to defaults based on the envelope provided
 func (e *FilterEnvelope) Reset(env *envelope.Envelope[int8]) {
-	e.state.Reset(env)
-	e.keyOn = false
-	e.prevKeyOn = false
-	e.update()
-}
-
-// SetEnabled sets the enabled flag for the envelope
-func (e *FilterEnvelope) SetEnabled(enabled bool) {
-	e.enabled = enabled
-}
-
-// IsEnabled returns the enabled flag for the envelope
-func (e *FilterEnvelope) IsEnabled() bool {
-	return e.enabled
-}
-
-// GetCurrentValue returns the current cached envelope value
-func (e *FilterEnvelope) GetCurrentValue() int8 {
-	return e.value
+	e.setup()
+	e.GenericEnvelope.Reset(env)
 }
 
-// SetEnvelopePosition sets the current position in the envelope
-func (e *FilterEnvelope) SetEnvelopePosition(pos int) voice.Callback {
-	keyOn := e.keyOn
-	prevKeyOn := e.prevKeyOn
-	env := e.state.Envelope()
-	e.state.Reset(env)
-	// TODO: this is gross, but currently the most optimal way to find the correct position
-	for i := 0; i < pos; i++ {
-		if doneCB := e.Advance(keyOn, prevKeyOn); doneCB != nil {
-			return doneCB
-		}
+// Carry resets the state based on the envelope provided, continuing on from the position of
+// the `prev` envelope (e.g. the previous voice on the channel) if the envelope's carry flag is set
+func (e *FilterEnvelope) Carry(prev *FilterEnvelope, env *envelope.Envelope[int8]) {
+	e.setup()
+	var prevEnv *GenericEnvelope[int8, int8]
+	if prev != nil {
+		prevEnv = &prev.GenericEnvelope
 	}
-	return nil
+	e.GenericEnvelope.Carry(prevEnv, env)
 }
 
-// Advance advances the envelope state 1 tick and calculates the current envelope value
-func (e *FilterEnvelope) Advance(keyOn bool, prevKeyOn bool) voice.Callback {
-	e.keyOn = keyOn
-	e.prevKeyOn = prevKeyOn
-	var doneCB voice.Callback
-	if done := e.state.Advance(e.keyOn, e.prevKeyOn); done {
-		doneCB = e.state.Envelope().OnFinished
-	}
-	e.update()
-	return doneCB
+func (e *FilterEnvelope) setup() {
+	e.Setup(0, filterValue, rampFilter)
 }
 
-func (e *FilterEnvelope) update() {
-	cur, next, t := e.state.GetCurrentValue(e.keyOn)
-
-	var y0 float32
-	if cur != nil {
-		y0 = float32(cur.Value())
-	}
-
-	var y1 float32
-	if next != nil {
-		y1 = float32(next.Value())
-	}
+func filterValue(y0 int8, y1 int8, t float32) int8 {
+	v0, v1 := float32(y0), float32(y1)
+	return int8(v0 + t*(v1-v0))
+}
 
-	e.value = int8(y0 + t*(y1-y0))
+func rampFilter(v0 int8, v1 int8, t float32) int8 {
+	y0, y1 := float64(v0), float64(v1)
+	return int8(math.Round(y0 + float64(t)*(y1-y0)))
 }
@@ -0,0 +1,181 @@
+package component
+
+import (
+	"time"
+
+	"github.com/gotracker/voice"
+	"github.com/gotracker/voice/envelope"
+)
+
+// EnvelopeValueFunc calculates the value of an envelope at `t` (0 through 1) of the way between the
+// point values `y0` and `y1`
+type EnvelopeValueFunc[T any, V any] func(y0 T, y1 T, t float32) V
+
+// EnvelopeRampFunc calculates the value at `t` (0 through 1) of the way between the envelope values
+// `v0` and `v1`
+type EnvelopeRampFunc[V any] func(v0 V, v1 V, t float32) V
+
+// GenericEnvelope is a modulation envelope for any parameter, with points of type T that are
+// mapped to values of type V
+type GenericEnvelope[T any, V any] struct {
+	enabled   bool
+	state     envelope.State[T]
+	missing   T
+	valueFn   EnvelopeValueFunc[T, V]
+	rampFn    EnvelopeRampFunc[V]
+	value     V
+	prevValue V
+	ramp      bool
+	keyOn     bool
+	prevKeyOn bool
+}
+
+// Setup sets the point value used when the envelope has none (e.g. when it is stopped), the function
+// that maps the point values onto the envelope value, and the function used for ramping between the
+// envelope values of successive ticks (which may be nil, for values that cannot be ramped)
+func (e *GenericEnvelope[T, V]) Setup(missing T, valueFn EnvelopeValueFunc[T, V], rampFn EnvelopeRampFunc[V]) {
+	e.missing = missing
+	e.valueFn = valueFn
+	e.rampFn = rampFn
+}
+
+// Reset resets the state to defaults based on the envelope provided
+func (e *GenericEnvelope[T, V]) Reset(env *envelope.Envelope[T]) {
+	e.state.Reset(env)
+	e.keyOn = false
+	e.prevKeyOn = false
+	e.update()
+	e.prevValue = e.value
+}
+
+// Restart resets the state to defaults based on the envelope it is already using
+func (e *GenericEnvelope[T, V]) Restart() {
+	e.Reset(e.state.Envelope())
+}
+
+// Carry resets the state based on the envelope provided, continuing on from the position of
+// the `prev` envelope (e.g. the previous voice on the channel) if the envelope's carry flag is set
+func (e *GenericEnvelope[T, V]) Carry(prev *GenericEnvelope[T, V], env *envelope.Envelope[T]) {
+	var prevState *envelope.State[T]
+	if prev != nil {
+		prevState = &prev.state
+	}
+	e.state.Carry(prevState, env)
+	e.keyOn = false
+	e.prevKeyOn = false
+	e.update()
+	e.prevValue = e.value
+}
+
+// SetEnabled sets the enabled flag for the envelope
+func (e *GenericEnvelope[T, V]) SetEnabled(enabled bool) {
+	e.enabled = enabled
+}
+
+// IsEnabled returns the enabled flag for the envelope
+func (e *GenericEnvelope[T, V]) IsEnabled() bool {
+	return e.enabled
+}
+
+// GetCurrentValue returns the current cached envelope value
+func (e *GenericEnvelope[T, V]) GetCurrentValue() V {
+	return e.value
+}
+
+// SetRampEnabled sets the ramp enabled flag for the envelope, which allows GetValueAt to
+// smoothly move from the value of the previous tick to the current one
+func (e *GenericEnvelope[T, V]) SetRampEnabled(enabled bool) {
+	e.ramp = enabled
+}
+
+// IsRampEnabled returns the ramp enabled flag for the envelope
+func (e *GenericEnvelope[T, V]) IsRampEnabled() bool {
+	return e.ramp
+}
+
+// GetValueAt returns the envelope value at the fraction `t` (0 through 1) of the way through the
+// current tick. When ramping is enabled (and there is a ramp function), this moves from the previous
+// tick's value to the current one. Otherwise, it returns the current value.
+func (e *GenericEnvelope[T, V]) GetValueAt(t float32) V {
+	if !e.ramp || e.rampFn == nil {
+		return e.value
+	}
+	return e.rampFn(e.prevValue, e.value, t)
+}
+
+// GetPlayhead returns the current playback position in the envelope
+func (e *GenericEnvelope[T, V]) GetPlayhead() envelope.Playhead {
+	return e.state.Playhead(e.keyOn)
+}
+
+// SetEnvelopePosition sets the current position in the envelope. The position is in ticks, or in
+// units of the envelope's time base for a time-based envelope (see SetEnvelopeTime).
+func (e *GenericEnvelope[T, V]) SetEnvelopePosition(pos int) voice.Callback {
+	var doneCB voice.Callback
+	if done := e.state.Seek(pos, e.keyOn, e.prevKeyOn); done {
+		doneCB = e.state.Envelope().OnFinished
+	}
+	e.prevValue = e.value
+	e.update()
+	return doneCB
+}
+
+// SetEnvelopeTime sets the current position in the envelope to the elapsed time since it started.
+// `tickDuration` converts the time to ticks for an envelope that is not time-based, so a position
+// in ticks (e.g. from an effect) can be given as ticks multiplied by the tick duration.
+func (e *GenericEnvelope[T, V]) SetEnvelopeTime(elapsed time.Duration, tickDuration time.Duration) voice.Callback {
+	var doneCB voice.Callback
+	if done := e.state.SeekTime(elapsed, tickDuration, e.keyOn, e.prevKeyOn); done {
+		doneCB = e.state.Envelope().OnFinished
+	}
+	e.prevValue = e.value
+	e.update()
+	return doneCB
+}
+
+// Advance advances the envelope state 1 tick and calculates the current envelope value
+func (e *GenericEnvelope[T, V]) Advance(keyOn bool, prevKeyOn bool) voice.Callback {
+	e.keyOn = keyOn
+	e.prevKeyOn = prevKeyOn
+	var doneCB voice.Callback
+	if done := e.state.Advance(e.keyOn, e.prevKeyOn); done {
+		doneCB = e.state.Envelope().OnFinished
+	}
+	e.prevValue = e.value
+	e.update()
+	return doneCB
+}
+
+// AdvanceTime advances the envelope state by the elapsed time (or by 1 tick, if the envelope is not
+// time-based) and calculates the current envelope value
+func (e *GenericEnvelope[T, V]) AdvanceTime(keyOn bool, prevKeyOn bool, elapsed time.Duration) voice.Callback {
+	e.keyOn = keyOn
+	e.prevKeyOn = prevKeyOn
+	var doneCB voice.Callback
+	if done := e.state.AdvanceTime(e.keyOn, e.prevKeyOn, elapsed); done {
+		doneCB = e.state.Envelope().OnFinished
+	}
+	e.prevValue = e.value
+	e.update()
+	return doneCB
+}
+
+func (e *GenericEnvelope[T, V]) update() {
+	if e.valueFn == nil {
+		return
+	}
+
+	cur, next, t := e.state.GetCurrentValue(e.keyOn)
+
+	y0 := e.missing
+	if cur != nil {
+		y0 = cur.Value()
+	}
+
+	y1 := e.missing
+	if next != nil {
+		y1 = next.Value()
+	}
+
+	e.value = e.valueFn(y0, y1, t)
+}
@@ -0,0 +1,95 @@
+package component
+
+import (
+	"time"
+
+	"github.com/gotracker/voice"
+)
+
+// ParamEnvelopes is a set of named envelopes driving additional voice parameters, kept in the
+// order in which they were registered
+type ParamEnvelopes struct {
+	names []string
+	envs  map[string]voice.ParamEnvelope
+}
+
+// Register adds the envelope under the name, replacing any envelope already registered with it
+func (p *ParamEnvelopes) Register(name string, env voice.ParamEnvelope) {
+	if p.envs == nil {
+		p.envs = make(map[string]voice.ParamEnvelope)
+	}
+	if _, ok := p.envs[name]; !ok {
+		p.names = append(p.names, name)
+	}
+	p.envs[name] = env
+}
+
+// Unregister removes the envelope registered under the name
+func (p *ParamEnvelopes) Unregister(name string) {
+	if _, ok := p.envs[name]; !ok {
+		return
+	}
+	delete(p.envs, name)
+	for i, n := range p.names {
+		if n == name {
+			p.names = append(p.names[:i], p.names[i+1:]...)
+			break
+		}
+	}
+}
+
+// Get returns the envelope registered under the name, or nil if there is none
+func (p *ParamEnvelopes) Get(name string) voice.ParamEnvelope {
+	return p.envs[name]
+}
+
+// Names returns the names of the registered envelopes, in the order they were registered
+func (p *ParamEnvelopes) Names() []string {
+	return append([]string(nil), p.names...)
+}
+
+// Restart resets all of the envelopes to their starting positions
+func (p *ParamEnvelopes) Restart() {
+	for _, n := range p.names {
+		p.envs[n].Restart()
+	}
+}
+
+// SetAllEnvelopePositions sets the current position of all of the envelopes, returning the
+// callbacks of any that finished on the way
+func (p *ParamEnvelopes) SetAllEnvelopePositions(pos int) []voice.Callback {
+	var callbacks []voice.Callback
+	for _, n := range p.names {
+		if cb := p.envs[n].SetEnvelopePosition(pos); cb != nil {
+			callbacks = append(callbacks, cb)
+		}
+	}
+	return callbacks
+}
+
+// Advance advances the enabled envelopes 1 tick, returning the callbacks of any that finished
+func (p *ParamEnvelopes) Advance(keyOn bool, prevKeyOn bool) []voice.Callback {
+	var callbacks []voice.Callback
+	for _, n := range p.names {
+		if env := p.envs[n]; env.IsEnabled() {
+			if cb := env.Advance(keyOn, prevKeyOn); cb != nil {
+				callbacks = append(callbacks, cb)
+			}
+		}
+	}
+	return callbacks
+}
+
+// AdvanceTime advances the enabled envelopes by the elapsed time (or by 1 tick, for those that are
+// not time-based), returning the callbacks of any that finished
+func (p *ParamEnvelopes) AdvanceTime(keyOn bool, prevKeyOn bool, elapsed time.Duration) []voice.Callback {
+	var callbacks []voice.Callback
+	for _, n := range p.names {
+		if env := p.envs[n]; env.IsEnabled() {
+			if cb := env.AdvanceTime(keyOn, prevKeyOn, elapsed); cb != nil {
+				callbacks = append(callbacks, cb)
+			}
+		}
+	}
+	return callbacks
+}
@@ -1,83 +1,54 @@
 package component
 
 import (
-	"github.com/gotracker/voice"
 	"github.com/gotracker/voice/envelope"
 	"github.com/gotracker/voice/period"
 )
 
 // PitchEnvelope is an frequency modulation envelope
 type PitchEnvelope struct {
-	enabled   bool
-	state     envelope.State[int8]
-	delta     period.Delta
-	keyOn     bool
-	prevKeyOn bool
+	// the value is kept as the (negated) pitch delta, so that it can be ramped
+	GenericEnvelope[int8, float32]
 }
 
 // Reset resets the state to defaults based on the envelope provided
 func (e *PitchEnvelope) Reset(env *envelope.Envelope[int8]) {
-	e.state.Reset(env)
-	e.keyOn = false
-	e.prevKeyOn = false
-	e.update()
+	e.setup()
+	e.GenericEnvelope.Reset(env)
 }
 
-// SetEnabled sets the enabled flag for the envelope
-func (e *PitchEnvelope) SetEnabled(enabled bool) {
-	e.enabled = enabled
-}
-
-// IsEnabled returns the enabled flag for the envelope
-func (e *PitchEnvelope) IsEnabled() bool {
-	return e.enabled
+// Carry resets the state based on the envelope provided, continuing on from the position of
+// the `prev` envelope (e.g. the previous voice on the channel) if the envelope's carry flag is set
+func (e *PitchEnvelope) Carry(prev *PitchEnvelope, env *envelope.Envelope[int8]) {
+	e.setup()
+	var prevEnv *GenericEnvelope[int8, float32]
+	if prev != nil {
+		prevEnv = &prev.GenericEnvelope
+	}
+	e.GenericEnvelope.Carry(prevEnv, env)
 }
 
 // GetCurrentValue returns the current cached envelope value
 func (e *PitchEnvelope) GetCurrentValue() period.Delta {
-	return e.delta
+	return period.Delta(e.GenericEnvelope.GetCurrentValue())
 }
 
-// SetEnvelopePosition sets the current position in the envelope
-func (e *PitchEnvelope) SetEnvelopePosition(pos int) voice.Callback {
-	keyOn := e.keyOn
-	prevKeyOn := e.prevKeyOn
-	env := e.state.Envelope()
-	e.state.Reset(env)
-	// TODO: this is gross, but currently the most optimal way to find the correct position
-	for i := 0; i < pos; i++ {
-		if doneCB := e.Advance(keyOn, prevKeyOn); doneCB != nil {
-			return doneCB
-		}
-	}
-	return nil
+// GetValueAt returns the envelope value at the fraction `t` (0 through 1) of the way through the
+// current tick. When ramping is enabled, this moves from the previous tick's value to the current
+// one. Otherwise, it returns the current value.
+func (e *PitchEnvelope) GetValueAt(t float32) period.Delta {
+	return period.Delta(e.GenericEnvelope.GetValueAt(t))
 }
 
-// Advance advances the envelope state 1 tick and calculates the current envelope value
-func (e *PitchEnvelope) Advance(keyOn bool, prevKeyOn bool) voice.Callback {
-	e.keyOn = keyOn
-	e.prevKeyOn = prevKeyOn
-	var doneCB voice.Callback
-	if done := e.state.Advance(e.keyOn, e.prevKeyOn); done {
-		doneCB = e.state.Envelope().OnFinished
-	}
-	e.update()
-	return doneCB
+func (e *PitchEnvelope) setup() {
+	e.Setup(0, pitchDelta, lerpFloat32)
 }
 
-func (e *PitchEnvelope) update() {
-	cur, next, t := e.state.GetCurrentValue(e.keyOn)
-
-	var y0 float32
-	if cur != nil {
-		y0 = float32(cur.Value())
-	}
-
-	var y1 float32
-	if next != nil {
-		y1 = float32(next.Value())
-	}
+func pitchDelta(y0 int8, y1 int8, t float32) float32 {
+	v0, v1 := float32(y0), float32(y1)
+	return -(v0 + t*(v1-v0))
+}
 
-	val := y0 + t*(y1-y0)
-	e.delta = period.Delta(-val)
+func lerpFloat32(v0 float32, v1 float32, t float32) float32 {
+	return v0 + t*(v1-v0)
 }
@@ -0,0 +1,142 @@
+package component
+
+import (
+	"testing"
+
+	"github.com/gotracker/gomixing/panning"
+	"github.com/gotracker/gomixing/volume"
+
+	"github.com/gotracker/voice"
+	"github.com/gotracker/voice/envelope"
+	"github.com/gotracker/voice/loop"
+	"github.com/gotracker/voice/period"
+)
+
+// newRampEnvelope returns an envelope moving linearly from y0 to y1 over `ticks` ticks
+func newRampEnvelope[T any](y0 T, y1 T, ticks int) *envelope.Envelope[T] {
+	return &envelope.Envelope[T]{
+		Enabled: true,
+		Loop:    &loop.Disabled{},
+		Sustain: &loop.Disabled{},
+		Values: []envelope.EnvPoint[T]{
+			{Ticks: ticks, Y: y0},
+			{Ticks: 1, Y: y1},
+		},
+	}
+}
+
+func TestComponentEnvelopeValues(t *testing.T) {
+	var ve VolumeEnvelope
+	ve.Reset(newRampEnvelope[volume.Volume](0, 1, 4))
+	ve.Advance(true, true)
+	if got := ve.GetCurrentValue(); got != 0.25 {
+		t.Errorf("volume: got %v, want 0.25", got)
+	}
+
+	var pe PanEnvelope
+	pe.Reset(newRampEnvelope(panning.CenterAhead, panning.CenterAhead, 4))
+	if got := pe.GetCurrentValue(); got != panning.CenterAhead {
+		t.Errorf("pan: got %v, want %v", got, panning.CenterAhead)
+	}
+
+	var ie PitchEnvelope
+	ie.Reset(newRampEnvelope[int8](0, 8, 4))
+	ie.Advance(true, true)
+	if got, want := ie.GetCurrentValue(), period.Delta(float32(-2)); got != want {
+		t.Errorf("pitch: got %v, want %v", got, want)
+	}
+
+	var fe FilterEnvelope
+	fe.Reset(newRampEnvelope[int8](0, 7, 4))
+	fe.Advance(true, true)
+	if got := fe.GetCurrentValue(); got != 1 {
+		t.Errorf("filter: got %v, want 1 (truncated from 1.75)", got)
+	}
+}
+
+func TestComponentEnvelopeRamp(t *testing.T) {
+	var ve VolumeEnvelope
+	ve.SetRampEnabled(true)
+	ve.Reset(newRampEnvelope[volume.Volume](0, 1, 4))
+	ve.Advance(true, true)
+	if got := ve.GetValueAt(0.5); got != 0.125 {
+		t.Errorf("volume: got %v, want 0.125", got)
+	}
+
+	var ie PitchEnvelope
+	ie.SetRampEnabled(true)
+	ie.Reset(newRampEnvelope[int8](0, 8, 4))
+	ie.Advance(true, true)
+	if got, want := ie.GetValueAt(0.5), period.Delta(float32(-1)); got != want {
+		t.Errorf("pitch: got %v, want %v", got, want)
+	}
+
+	// the filter value ramps from 0 to 2 over the tick, rounding to the nearest step
+	var fe FilterEnvelope
+	fe.SetRampEnabled(true)
+	fe.Reset(newRampEnvelope[int8](0, 8, 4))
+	fe.Advance(true, true)
+	for _, tc := range []struct {
+		t    float32
+		want int8
+	}{
+		{0, 0},
+		{0.2, 0},
+		{0.3, 1},
+		{0.7, 1},
+		{0.8, 2},
+		{1, 2},
+	} {
+		if got := fe.GetValueAt(tc.t); got != tc.want {
+			t.Errorf("filter at %v: got %v, want %v", tc.t, got, tc.want)
+		}
+	}
+}
+
+func TestComponentEnvelopeCarry(t *testing.T) {
+	env := newRampEnvelope[volume.Volume](0, 1, 4)
+	env.Carry = true
+
+	var prev VolumeEnvelope
+	prev.Reset(env)
+	prev.Advance(true, true)
+	prev.Advance(true, true)
+
+	var ve VolumeEnvelope
+	ve.Carry(&prev, env)
+	if got := ve.GetCurrentValue(); got != 0.5 {
+		t.Errorf("got %v, want 0.5", got)
+	}
+
+	// a nil previous envelope starts from the beginning
+	ve.Carry(nil, env)
+	if got := ve.GetCurrentValue(); got != 0 {
+		t.Errorf("got %v, want 0", got)
+	}
+}
+
+func TestComponentEnvelopesProvideParamValues(t *testing.T) {
+	var env voice.ParamEnvelope = &VolumeEnvelope{}
+	if _, ok := env.(voice.ParamEnvelopeValue[volume.Volume]); !ok {
+		t.Error("volume: does not provide a volume.Volume value")
+	}
+	env = &PanEnvelope{}
+	if _, ok := env.(voice.ParamEnvelopeValue[panning.Position]); !ok {
+		t.Error("pan: does not provide a panning.Position value")
+	}
+	env = &PitchEnvelope{}
+	if _, ok := env.(voice.ParamEnvelopeValue[period.Delta]); !ok {
+		t.Error("pitch: does not provide a period.Delta value")
+	}
+	env = &FilterEnvelope{}
+	if _, ok := env.(voice.ParamEnvelopeValue[int8]); !ok {
+		t.Error("filter: does not provide an int8 value")
+	}
+	env = &GenericEnvelope[int8, float64]{}
+	if _, ok := env.(voice.ParamEnvelopeValue[float64]); !ok {
+		t.Error("generic: does not provide a float64 value")
+	}
+	if _, ok := env.(voice.ParamEnvelopeValue[int8]); ok {
+		t.Error("generic: provides an int8 value")
+	}
+}
@@ -3,81 +3,35 @@ package component
 import (
 	"github.com/gotracker/gomixing/volume"
 
-	"github.com/gotracker/voice"
 	"github.com/gotracker/voice/envelope"
 )
 
 // VolumeEnvelope is an amplitude modulation envelope
 type VolumeEnvelope struct {
-	enabled   bool
-	state     envelope.State[volume.Volume]
-	vol       volume.Volume
-	keyOn     bool
-	prevKeyOn bool
+	GenericEnvelope[volume.Volume, volume.Volume]
 }
 
 // Reset resets the state to defaults based on the envelope provided
 func (e *VolumeEnvelope) Reset(env *envelope.Envelope[volume.Volume]) {
-	e.state.Reset(env)
-	e.keyOn = false
-	e.prevKeyOn = false
-	e.update()
+	e.setup()
+	e.GenericEnvelope.Reset(env)
 }
 
-// SetEnabled sets the enabled flag for the envelope
-func (e *VolumeEnvelope) SetEnabled(enabled bool) {
-	e.enabled = enabled
-}
-
-// IsEnabled returns the enabled flag for the envelope
-func (e *VolumeEnvelope) IsEnabled() bool {
-	return e.enabled
-}
-
-// GetCurrentValue returns the current cached envelope value
-func (e *VolumeEnvelope) GetCurrentValue() volume.Volume {
-	return e.vol
-}
-
-// SetEnvelopePosition sets the current position in the envelope
-func (e *VolumeEnvelope) SetEnvelopePosition(pos int) voice.Callback {
-	keyOn := e.keyOn
-	prevKeyOn := e.prevKeyOn
-	env := e.state.Envelope()
-	e.state.Reset(env)
-	// TODO: this is gross, but currently the most optimal way to find the correct position
-	for i := 0; i < pos; i++ {
-		if doneCB := e.Advance(keyOn, prevKeyOn); doneCB != nil {
-			return doneCB
-		}
+// Carry resets the state based on the envelope provided, continuing on from the position of
+// the `prev` envelope (e.g. the previous voice on the channel) if the envelope's carry flag is set
+func (e *VolumeEnvelope) Carry(prev *VolumeEnvelope, env *envelope.Envelope[volume.Volume]) {
+	e.setup()
+	var prevEnv *GenericEnvelope[volume.Volume, volume.Volume]
+	if prev != nil {
+		prevEnv = &prev.GenericEnvelope
 	}
-	return nil
+	e.GenericEnvelope.Carry(prevEnv, env)
 }
 
-// Advance advances the envelope state 1 tick and calculates the current envelope value
-func (e *VolumeEnvelope) Advance(keyOn bool, prevKeyOn bool) voice.Callback {
-	e.keyOn = keyOn
-	e.prevKeyOn = prevKeyOn
-	var doneCB voice.Callback
-	if done := e.state.Advance(e.keyOn, e.prevKeyOn); done {
-		doneCB = e.state.Envelope().OnFinished
-	}
-	e.update()
-	return doneCB
+func (e *VolumeEnvelope) setup() {
+	e.Setup(0, lerpVolume, lerpVolume)
 }
 
-func (e *VolumeEnvelope) update() {
-	cur, next, t := e.state.GetCurrentValue(e.keyOn)
-
-	var y0 volume.Volume
-	if cur != nil {
-		y0 = cur.Value()
-	}
-
-	var y1 volume.Volume
-	if next != nil {
-		y1 = next.Value()
-	}
-
-	e.vol = y0 + volume.Volume(t)*(y1-y0)
+func lerpVolume(v0 volume.Volume, v1 volume.Volume, t float32) volume.Volume {
+	return v0 + volume.Volume(t)*(v1-v0)
 }
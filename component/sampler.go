@@ -1,6 +1,8 @@
 package component
 
 import (
+	"math"
+
 	"github.com/gotracker/gomixing/sampling"
 	"github.com/gotracker/gomixing/volume"
 
@@ -35,6 +37,15 @@ func (s *Sampler) GetPos() sampling.Pos {
 	return s.pos
 }
 
+// GetLoopIteration returns the number of completed iterations of the active loop at the current
+// position, or 0 if the active loop does not track its iterations (such as a loop.Legacy)
+func (s *Sampler) GetLoopIteration() int {
+	if ic, ok := s.activeLoop().(loop.IterationCounter); ok {
+		return ic.CalcIteration(s.pos.Pos)
+	}
+	return 0
+}
+
 // Attack sets the key-on value (for loop processing)
 func (s *Sampler) Attack() {
 	s.keyOn = true
@@ -42,10 +53,50 @@ func (s *Sampler) Attack() {
 }
 
 // Release releases the key-on value (for loop processing)
+// If the sustain loop was active, the position is rebased to where playback currently is within
+// the sustain loop, so that playback continues from there (instead of jumping somewhere unrelated
+// in the whole loop or the tail of the sample), as Impulse Tracker does.
+// When a ping-pong sustain loop is released on a reversed pass, the direction is kept the way
+// OpenMPT does it: playback carries on in reverse if the whole loop is also a ping-pong loop that
+// the position is within (from the matching place on its first reversed pass), otherwise it
+// continues forward from the same offset.
+// The rebased position is only seen by callers that read it back with GetPos; GetSample uses
+// whatever position it is given.
 func (s *Sampler) Release() {
+	if s.keyOn && s.loopsEnabled && s.sample != nil && s.sustainLoop.Enabled() {
+		if fl, ok := s.sustainLoop.(*loop.Fractional); ok {
+			p, _ := fl.CalcPosFrac(float64(s.pos.Pos)+float64(s.pos.Frac), s.sample.Length())
+			ip := math.Floor(p)
+			s.pos.Pos = int(ip)
+			s.pos.Frac = float32(p - ip)
+		} else {
+			reversed := false
+			if pp, ok := s.sustainLoop.(*loop.PingPong); ok {
+				reversed = pp.CalcIteration(s.pos.Pos)%2 == 1
+			}
+			s.pos.Pos, _ = s.sustainLoop.CalcPos(s.pos.Pos, s.sample.Length())
+			if wl, ok := s.wholeLoop.(*loop.PingPong); ok && reversed {
+				s.pos.Pos = reversedPingPongPos(wl, s.pos.Pos, s.sample.Length())
+			}
+		}
+	}
 	s.keyOn = false
 }
 
+// reversedPingPongPos returns the position on the first reversed pass of the ping-pong loop that
+// plays the sample at `pos`, or `pos` itself if no reversed pass plays it
+func reversedPingPongPos(l *loop.PingPong, pos int, length int) int {
+	if pos < l.Begin || pos >= l.End {
+		return pos
+	}
+	for p := l.End; p < l.End+l.Length()+1; p++ {
+		if lp, _ := l.CalcPos(p, length); lp == pos && l.CalcIteration(p)%2 == 1 {
+			return p
+		}
+	}
+	return pos
+}
+
 // Fadeout disables the loops (for loop processing)
 func (s *Sampler) Fadeout() {
 	s.loopsEnabled = false
@@ -53,6 +104,10 @@ func (s *Sampler) Fadeout() {
 
 // GetSample returns a multi-channel sample at the specified position
 func (s *Sampler) GetSample(pos sampling.Pos) volume.Matrix {
+	if fl, ok := s.activeLoop().(*loop.Fractional); ok && s.sample != nil && s.canLoop() {
+		return s.getFractionalSample(fl, float64(pos.Pos)+float64(pos.Frac))
+	}
+
 	v0 := s.getConvertedSample(pos.Pos)
 	if v0.Channels == 0 {
 		if s.canLoop() {
@@ -70,6 +125,42 @@ func (s *Sampler) GetSample(pos sampling.Pos) volume.Matrix {
 	return v0.Lerp(v1, pos.Frac)
 }
 
+// getFractionalSample returns a multi-channel sample at the exact position `x` within a loop that
+// has sub-sample loop points, interpolating across the loop end into the loop begin
+func (s *Sampler) getFractionalSample(fl *loop.Fractional, x float64) volume.Matrix {
+	lp, _ := fl.CalcPosFrac(x, s.sample.Length())
+	ip := math.Floor(lp)
+	v0 := s.getRawSample(int(ip))
+	t := float32(lp - ip)
+	if t == 0 {
+		return v0
+	}
+
+	next := ip + 1
+	if next >= fl.End {
+		// the next sample is past the loop end, so it comes from just after the loop begin
+		next -= fl.LengthFrac()
+	}
+	in := math.Floor(next)
+	v1 := s.getRawSample(int(in))
+	if nt := float32(next - in); nt != 0 {
+		v1 = v1.Lerp(s.getRawSample(int(in)+1), nt)
+	}
+	return v0.Lerp(v1, t)
+}
+
+// getRawSample returns a multi-channel sample at the specified position, without loop processing
+func (s *Sampler) getRawSample(pos int) volume.Matrix {
+	if pos < 0 || pos >= s.sample.Length() {
+		return volume.Matrix{}
+	}
+	data, err := s.readSample(pos)
+	if err != nil {
+		return volume.Matrix{}
+	}
+	return data
+}
+
 func (s *Sampler) canLoop() bool {
 	switch {
 	case !s.loopsEnabled:
@@ -96,10 +187,43 @@ func (s *Sampler) getConvertedSample(pos int) volume.Matrix {
 	if pos < 0 || pos >= sl {
 		return volume.Matrix{}
 	}
-	s.sample.Seek(pos)
-	data, err := s.sample.Read()
+	data, err := s.readSample(pos)
 	if err != nil {
 		return volume.Matrix{}
 	}
+
+	if xf, ok := s.activeLoop().(*loop.Crossfade); ok && s.canLoop() {
+		if other, gain, otherGain, blend := xf.CalcCrossfade(pos); blend && other >= 0 {
+			if odata, err := s.readSample(other); err == nil {
+				data = crossfadeMatrix(data, odata, gain, otherGain)
+			}
+		}
+	}
 	return data
 }
+
+func (s *Sampler) readSample(pos int) (volume.Matrix, error) {
+	s.sample.Seek(pos)
+	return s.sample.Read()
+}
+
+// activeLoop returns the loop that CalcLoopPos would use for the current key-on state
+func (s *Sampler) activeLoop() loop.Loop {
+	if s.keyOn && s.sustainLoop.Enabled() {
+		return s.sustainLoop
+	}
+	return s.wholeLoop
+}
+
+func crossfadeMatrix(a, b volume.Matrix, gainA, gainB float32) volume.Matrix {
+	out := volume.Matrix{
+		Channels: a.Channels,
+	}
+	for c := 0; c < a.Channels; c++ {
+		out.StaticMatrix[c] = a.StaticMatrix[c] * volume.Volume(gainA)
+		if c < b.Channels {
+			out.StaticMatrix[c] += b.StaticMatrix[c] * volume.Volume(gainB)
+		}
+	}
+	return out
+}
@@ -0,0 +1,208 @@
+package component
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gotracker/gomixing/sampling"
+	"github.com/gotracker/gomixing/volume"
+
+	"github.com/gotracker/voice/loop"
+	"github.com/gotracker/voice/pcm"
+)
+
+// newRampSample returns a mono sample where the value of each sample is its own offset
+func newRampSample(length int) pcm.Sample {
+	data := make([]volume.Matrix, length)
+	for i := range data {
+		data[i].Channels = 1
+		data[i].StaticMatrix[0] = volume.Volume(i)
+	}
+	return pcm.NewSampleNative(data, length, 1)
+}
+
+func TestSamplerReleaseRebasesToSustainLoop(t *testing.T) {
+	tests := []struct {
+		name      string
+		sustain   loop.Loop
+		whole     loop.Loop
+		pos       sampling.Pos
+		wantPos   sampling.Pos
+		wantAfter []volume.Volume // values at the rebased position and the following ones
+	}{
+		{
+			name:      "normal sustain, no whole loop",
+			sustain:   &loop.Normal{Settings: loop.Settings{Begin: 4, End: 8}},
+			whole:     &loop.Disabled{},
+			pos:       sampling.Pos{Pos: 13},
+			wantPos:   sampling.Pos{Pos: 5},
+			wantAfter: []volume.Volume{5, 6, 7, 8, 9},
+		},
+		{
+			name:      "normal sustain into whole loop",
+			sustain:   &loop.Normal{Settings: loop.Settings{Begin: 4, End: 8}},
+			whole:     &loop.Normal{Settings: loop.Settings{Begin: 2, End: 10}},
+			pos:       sampling.Pos{Pos: 15, Frac: 0.5},
+			wantPos:   sampling.Pos{Pos: 7, Frac: 0.5},
+			wantAfter: []volume.Volume{7.5, 8.5, 5.5, 2.5}, // 9.5 blends into the whole loop begin
+		},
+		{
+			name:    "not yet in the sustain loop",
+			sustain: &loop.Normal{Settings: loop.Settings{Begin: 4, End: 8}},
+			whole:   &loop.Disabled{},
+			pos:     sampling.Pos{Pos: 3},
+			wantPos: sampling.Pos{Pos: 3},
+		},
+		{
+			// with no ping-pong whole loop to carry the direction on in, the offset is kept and
+			// playback continues forward from it (OpenMPT clears the reverse flag on key-off)
+			name:      "ping-pong sustain released on a reversed pass",
+			sustain:   &loop.PingPong{Settings: loop.Settings{Begin: 4, End: 8}},
+			whole:     &loop.Disabled{},
+			pos:       sampling.Pos{Pos: 10},
+			wantPos:   sampling.Pos{Pos: 5},
+			wantAfter: []volume.Volume{5, 6, 7, 8},
+		},
+		{
+			// the reverse flag is kept on key-off when the whole loop is a ping-pong loop, so
+			// playback carries on backwards from the same sample: 5 plays on the whole loop's
+			// first reversed pass at 12+(11-5)
+			name:      "ping-pong sustain released on a reversed pass into a ping-pong whole loop",
+			sustain:   &loop.PingPong{Settings: loop.Settings{Begin: 4, End: 8}},
+			whole:     &loop.PingPong{Settings: loop.Settings{Begin: 2, End: 12}},
+			pos:       sampling.Pos{Pos: 10},
+			wantPos:   sampling.Pos{Pos: 18},
+			wantAfter: []volume.Volume{5, 4, 3, 2, 2, 3},
+		},
+		{
+			name:      "ping-pong sustain released on a forward pass into a ping-pong whole loop",
+			sustain:   &loop.PingPong{Settings: loop.Settings{Begin: 4, End: 8}},
+			whole:     &loop.PingPong{Settings: loop.Settings{Begin: 2, End: 12}},
+			pos:       sampling.Pos{Pos: 13},
+			wantPos:   sampling.Pos{Pos: 5},
+			wantAfter: []volume.Volume{5, 6, 7, 8},
+		},
+		{
+			name:      "ping-pong sustain released on a reversed pass into a normal whole loop",
+			sustain:   &loop.PingPong{Settings: loop.Settings{Begin: 4, End: 8}},
+			whole:     &loop.Normal{Settings: loop.Settings{Begin: 2, End: 12}},
+			pos:       sampling.Pos{Pos: 10},
+			wantPos:   sampling.Pos{Pos: 5},
+			wantAfter: []volume.Volume{5, 6, 7, 8},
+		},
+		{
+			name:      "fractional sustain",
+			sustain:   &loop.Fractional{FractionalSettings: loop.FractionalSettings{Begin: 4.5, End: 8}},
+			whole:     &loop.Disabled{},
+			pos:       sampling.Pos{Pos: 9, Frac: 0.25},
+			wantPos:   sampling.Pos{Pos: 5, Frac: 0.75},
+			wantAfter: []volume.Volume{5.75, 6.75},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s Sampler
+			s.Setup(newRampSample(16), tt.whole, tt.sustain)
+			s.Attack()
+			s.SetPos(tt.pos)
+			s.Release()
+
+			if got := s.GetPos(); got != tt.wantPos {
+				t.Fatalf("got position %+v, want %+v", got, tt.wantPos)
+			}
+			for i, want := range tt.wantAfter {
+				pos := s.GetPos()
+				pos.Pos += i
+				if got := s.GetSample(pos).StaticMatrix[0]; got != want {
+					t.Errorf("sample %d after release: got %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSamplerReleaseWithoutKeyOn(t *testing.T) {
+	var s Sampler
+	s.Setup(newRampSample(16), &loop.Disabled{}, &loop.Normal{Settings: loop.Settings{Begin: 4, End: 8}})
+	s.SetPos(sampling.Pos{Pos: 13})
+	s.Release()
+	if got := s.GetPos(); got.Pos != 13 {
+		t.Fatalf("got position %+v, want 13 (no sustain loop was playing)", got)
+	}
+}
+
+// newSineSample returns a mono sample of a sine wave that does not repeat within the sample
+func newSineSample(length int) pcm.Sample {
+	data := make([]volume.Matrix, length)
+	for i := range data {
+		data[i].Channels = 1
+		data[i].StaticMatrix[0] = volume.Volume(math.Sin(float64(i) * 0.37))
+	}
+	return pcm.NewSampleNative(data, length, 1)
+}
+
+func TestSamplerCrossfadeMatchesBaked(t *testing.T) {
+	for _, curve := range []loop.CrossfadeCurve{loop.CrossfadeCurveLinear, loop.CrossfadeCurveEqualPower} {
+		xf := &loop.Crossfade{
+			Settings: loop.Settings{Begin: 8, End: 24},
+			Fade:     6,
+			Curve:    curve,
+		}
+		sample := newSineSample(32)
+		baked, err := pcm.BakeCrossfade(sample, xf, pcm.SampleDataFormat64BitLEFloat)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var live, plain Sampler
+		live.Setup(sample, xf, &loop.Disabled{})
+		live.Attack()
+		plain.Setup(baked, &loop.Normal{Settings: xf.Settings}, &loop.Disabled{})
+		plain.Attack()
+
+		// the last sample before the loop end is the one just before the loop begin
+		if got, want := live.GetSample(sampling.Pos{Pos: 23}).StaticMatrix[0], math.Sin(7*0.37); math.Abs(float64(got)-want) > 1e-6 {
+			t.Errorf("curve %v: got %v before the loop end, want %v", curve, got, want)
+		}
+
+		// from the first pass onwards, including several trips around the loop
+		for pos := 0; pos < 80; pos++ {
+			p := sampling.Pos{Pos: pos}
+			got := live.GetSample(p).StaticMatrix[0]
+			want := plain.GetSample(p).StaticMatrix[0]
+			if math.Abs(float64(got-want)) > 1e-6 {
+				t.Errorf("curve %v, pos %d: live %v, baked %v", curve, pos, got, want)
+			}
+		}
+	}
+}
+
+func TestSamplerFractionalLoop(t *testing.T) {
+	// the loop is 2.5 samples long, so the sample after 4 is the one halfway between 2 and 3
+	var s Sampler
+	s.Setup(newRampSample(10), &loop.Fractional{FractionalSettings: loop.FractionalSettings{Begin: 2, End: 4.5}}, &loop.Disabled{})
+	s.Attack()
+
+	tests := []struct {
+		pos  sampling.Pos
+		want volume.Volume
+	}{
+		{sampling.Pos{Pos: 3, Frac: 0.5}, 3.5},
+		{sampling.Pos{Pos: 4}, 4},
+		{sampling.Pos{Pos: 4, Frac: 0.25}, 3.625}, // across the seam, from 4 towards 2.5
+		{sampling.Pos{Pos: 4, Frac: 0.5}, 2},      // first wrap
+		{sampling.Pos{Pos: 5, Frac: 0.4}, 2.9},
+		{sampling.Pos{Pos: 6, Frac: 0.75}, 3.625}, // across the seam on the second pass
+		{sampling.Pos{Pos: 7}, 2},
+		{sampling.Pos{Pos: 9, Frac: 0.5}, 2}, // third wrap
+		{sampling.Pos{Pos: 11, Frac: 0.75}, 3.625},
+		{sampling.Pos{Pos: 1002, Frac: 0.25}, 2.25}, // 400 wraps
+	}
+	for _, tc := range tests {
+		got := s.GetSample(tc.pos)
+		if got.Channels != 1 || math.Abs(float64(got.StaticMatrix[0]-tc.want)) > 1e-4 {
+			t.Errorf("%v: got %v, want %v", tc.pos, got.StaticMatrix[0], tc.want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package envelope
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/gotracker/voice/loop"
+)
+
+// TimeUnit is the unit of the stage lengths of an ADSR or DAHDSR description
+type TimeUnit uint8
+
+const (
+	// TimeUnitTicks is for stage lengths in ticks
+	TimeUnitTicks = TimeUnit(iota)
+	// TimeUnitMilliseconds is for stage lengths in milliseconds
+	TimeUnitMilliseconds
+)
+
+var (
+	// ErrNegativeStageLength is for when an ADSR stage has a negative length
+	ErrNegativeStageLength = errors.New("envelope stage length is negative")
+	// ErrInvalidTickDuration is for when a time-based stage length is converted with an invalid tick duration
+	ErrInvalidTickDuration = errors.New("invalid tick duration")
+	// ErrNotTimeBased is for when a time-based envelope is built from stage lengths that are not time-based
+	ErrNotTimeBased = errors.New("envelope stage lengths are not time-based")
+)
+
+// ADSR is an attack/decay/sustain/release description of an envelope
+type ADSR[T any] struct {
+	Unit    TimeUnit
+	Attack  int // time taken to move from Start to Peak
+	Decay   int // time taken to move from Peak to Sustain
+	Release int // time taken to move from Sustain to End, once the key is released
+
+	Start   T // level at key-on
+	Peak    T // level at the end of the attack
+	Sustain T // level held while the key is on
+	End     T // level at the end of the release
+
+	AttackCurve  Interpolation
+	DecayCurve   Interpolation
+	ReleaseCurve Interpolation
+}
+
+// DAHDSR converts the description to a delay/attack/hold/decay/sustain/release description
+func (p ADSR[T]) DAHDSR() DAHDSR[T] {
+	return DAHDSR[T]{
+		Unit:         p.Unit,
+		Attack:       p.Attack,
+		Decay:        p.Decay,
+		Release:      p.Release,
+		Start:        p.Start,
+		Peak:         p.Peak,
+		Sustain:      p.Sustain,
+		End:          p.End,
+		AttackCurve:  p.AttackCurve,
+		DecayCurve:   p.DecayCurve,
+		ReleaseCurve: p.ReleaseCurve,
+	}
+}
+
+// Build creates an envelope from the description. See DAHDSR.Build for details.
+func (p ADSR[T]) Build(tickDuration time.Duration) (*Envelope[T], error) {
+	return p.DAHDSR().Build(tickDuration)
+}
+
+// BuildTimeBased creates a time-based envelope from the description. See DAHDSR.BuildTimeBased for details.
+func (p ADSR[T]) BuildTimeBased() (*Envelope[T], error) {
+	return p.DAHDSR().BuildTimeBased()
+}
+
+// DAHDSR is a delay/attack/hold/decay/sustain/release description of an envelope
+type DAHDSR[T any] struct {
+	Unit    TimeUnit
+	Delay   int // time spent at Start before the attack begins
+	Attack  int // time taken to move from Start to Peak
+	Hold    int // time spent at Peak before the decay begins
+	Decay   int // time taken to move from Peak to Sustain
+	Release int // time taken to move from Sustain to End, once the key is released
+
+	Start   T // level at key-on
+	Peak    T // level at the end of the attack
+	Sustain T // level held while the key is on
+	End     T // level at the end of the release
+
+	AttackCurve  Interpolation
+	DecayCurve   Interpolation
+	ReleaseCurve Interpolation
+}
+
+// Build creates an enabled envelope from the description, with a sustain loop holding the
+// sustain level while the key is on, and a loop holding the end level once the release is over
+// (so the envelope does not finish). Stages with no length are left out, except that the peak level
+// is always reached: when there is no hold or decay, it is held for a single tick. When the stage
+// lengths are in milliseconds, `tickDuration` is used to convert them to ticks (otherwise it is ignored).
+// As with tracker envelopes, releasing the key before the sustain point is reached lets the
+// attack and decay finish before the release begins.
+func (p DAHDSR[T]) Build(tickDuration time.Duration) (*Envelope[T], error) {
+	toTicks := func(v int) (int, error) {
+		if v < 0 {
+			return 0, ErrNegativeStageLength
+		}
+		if p.Unit != TimeUnitMilliseconds || v == 0 {
+			return v, nil
+		}
+		if tickDuration <= 0 {
+			return 0, ErrInvalidTickDuration
+		}
+		ticks := int(math.Round(float64(time.Duration(v)*time.Millisecond) / float64(tickDuration)))
+		if ticks < 1 {
+			ticks = 1
+		}
+		return ticks, nil
+	}
+
+	var stages [5]int
+	for i, v := range []int{p.Delay, p.Attack, p.Hold, p.Decay, p.Release} {
+		ticks, err := toTicks(v)
+		if err != nil {
+			return nil, err
+		}
+		stages[i] = ticks
+	}
+	delay, attack, hold, decay, release := stages[0], stages[1], stages[2], stages[3], stages[4]
+
+	env := Envelope[T]{
+		Enabled: true,
+	}
+	addPoint := func(ticks int, y T, interp Interpolation) {
+		env.Values = append(env.Values, EnvPoint[T]{
+			Ticks:         ticks,
+			Y:             y,
+			Interpolation: interp,
+		})
+	}
+
+	if delay > 0 {
+		addPoint(delay, p.Start, InterpolationStep)
+	}
+	if attack > 0 {
+		addPoint(attack, p.Start, p.AttackCurve)
+	}
+	if hold > 0 {
+		addPoint(hold, p.Peak, InterpolationStep)
+	}
+	if decay > 0 {
+		addPoint(decay, p.Peak, p.DecayCurve)
+	}
+	if hold == 0 && decay == 0 {
+		addPoint(1, p.Peak, InterpolationStep)
+	}
+
+	// the sustain point is a single-tick point that the sustain loop repeats while the key is on
+	sustain := len(env.Values)
+	addPoint(1, p.Sustain, InterpolationStep)
+	env.Sustain = &loop.Normal{
+		Settings: loop.Settings{
+			Begin: sustain,
+			End:   sustain + 1,
+		},
+	}
+
+	if release > 0 {
+		addPoint(release, p.Sustain, p.ReleaseCurve)
+	}
+
+	// the end point is a single-tick point that the loop repeats once the key is released
+	end := len(env.Values)
+	addPoint(1, p.End, InterpolationStep)
+	env.Loop = &loop.Normal{
+		Settings: loop.Settings{
+			Begin: end,
+			End:   end + 1,
+		},
+	}
+	return &env, nil
+}
+
+// BuildTimeBased creates an enabled, time-based envelope (one that advances by elapsed time instead
+// of by ticks) from a description with stage lengths in milliseconds, so that its timing does not
+// depend on the tick rate
+func (p DAHDSR[T]) BuildTimeBased() (*Envelope[T], error) {
+	if p.Unit != TimeUnitMilliseconds {
+		return nil, ErrNotTimeBased
+	}
+
+	ms := p
+	ms.Unit = TimeUnitTicks
+	env, err := ms.Build(0)
+	if err != nil {
+		return nil, err
+	}
+	env.TimeBase = time.Millisecond
+	return env, nil
+}
@@ -0,0 +1,103 @@
+package envelope
+
+import (
+	"math"
+	"testing"
+)
+
+// render returns the value of the envelope at each tick, releasing the key after `keyOnTicks` ticks
+// (-1 for a stopped envelope)
+func render(env *Envelope[int], ticks int, keyOnTicks int) []float32 {
+	var s State[int]
+	s.Reset(env)
+	out := make([]float32, ticks)
+	keyOn := true
+	for i := range out {
+		cur, next, t := s.GetCurrentValue(keyOn)
+		if cur == nil {
+			out[i] = -1
+		} else {
+			out[i] = float32(cur.Y) + t*float32(next.Y-cur.Y)
+		}
+		prevKeyOn := keyOn
+		keyOn = i+1 < keyOnTicks
+		s.Advance(keyOn, prevKeyOn)
+	}
+	return out
+}
+
+func closeEnough(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(float64(a[i]-b[i])) > 1e-4 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestADSRBuild(t *testing.T) {
+	tests := []struct {
+		name       string
+		adsr       DAHDSR[int]
+		keyOnTicks int
+		want       []float32
+	}{
+		{
+			name:       "attack, decay and release",
+			adsr:       ADSR[int]{Attack: 2, Decay: 2, Release: 2, Start: 0, Peak: 64, Sustain: 32, End: 8}.DAHDSR(),
+			keyOnTicks: 6,
+			want:       []float32{0, 32, 64, 48, 32, 32, 32, 20, 8, 8, 8, 8},
+		},
+		{
+			name:       "peak is reached without a hold or decay",
+			adsr:       ADSR[int]{Attack: 3, Peak: 64, Sustain: 32}.DAHDSR(),
+			keyOnTicks: 6,
+			want:       []float32{0, 21.333334, 42.666668, 64, 32, 32, 0, 0},
+		},
+		{
+			name:       "end level is held after the release",
+			adsr:       ADSR[int]{Release: 2, Peak: 32, Sustain: 32, End: 8}.DAHDSR(),
+			keyOnTicks: 3,
+			want:       []float32{32, 32, 32, 32, 20, 8, 8, 8, 8},
+		},
+		{
+			name:       "released before the sustain point",
+			adsr:       ADSR[int]{Attack: 2, Decay: 2, Release: 2, Start: 0, Peak: 64, Sustain: 32, End: 8}.DAHDSR(),
+			keyOnTicks: 1,
+			want:       []float32{0, 32, 64, 48, 32, 32, 20, 8, 8},
+		},
+		{
+			name:       "delay and hold",
+			adsr:       DAHDSR[int]{Delay: 2, Attack: 1, Hold: 2, Decay: 1, Start: 4, Peak: 64, Sustain: 32, End: 0},
+			keyOnTicks: 8,
+			want:       []float32{4, 4, 4, 64, 64, 64, 32, 32, 0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := tt.adsr.Build(0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := render(env, len(tt.want), tt.keyOnTicks); !closeEnough(got, tt.want) {
+				t.Errorf("got  %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestADSRBuildErrors(t *testing.T) {
+	if _, err := (ADSR[int]{Attack: -1}).Build(0); err != ErrNegativeStageLength {
+		t.Errorf("negative stage: got %v", err)
+	}
+	if _, err := (ADSR[int]{Unit: TimeUnitMilliseconds, Attack: 10}).Build(0); err != ErrInvalidTickDuration {
+		t.Errorf("no tick duration: got %v", err)
+	}
+	if _, err := (ADSR[int]{Attack: 10}).BuildTimeBased(); err != ErrNotTimeBased {
+		t.Errorf("not time-based: got %v", err)
+	}
+}
@@ -0,0 +1,354 @@
+package envelope
+
+import (
+	"errors"
+
+	"github.com/gotracker/voice/loop"
+)
+
+var (
+	// ErrPointOutOfRange is for when an envelope point index is outside of the points
+	ErrPointOutOfRange = errors.New("envelope point index out of range")
+	// ErrPointExists is for when a point is inserted at the same tick as an existing point
+	ErrPointExists = errors.New("envelope point already exists at tick")
+)
+
+// Ordered is the set of value types that can be clamped
+type Ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// PointEdit describes how an edit changed the indices of an envelope's points
+type PointEdit struct {
+	Index int // index of the point that was inserted, deleted or changed
+	Shift int // +1 when a point was inserted, -1 when one was deleted, 0 when one was changed in place
+}
+
+// PointTick returns the tick (from the start of the envelope) at which the point is reached
+func (e *Envelope[T]) PointTick(index int) (int, error) {
+	if index < 0 || index >= len(e.Values) {
+		return 0, ErrPointOutOfRange
+	}
+	tick := 0
+	for _, p := range e.Values[:index] {
+		tick += p.Length()
+	}
+	return tick, nil
+}
+
+// InsertPoint adds a point with the value at the tick (from the start of the envelope), splitting
+// the segment that the tick falls within so that the other points keep their timing. The loop and
+// sustain ranges are adjusted to keep covering the same points.
+func (e *Envelope[T]) InsertPoint(tick int, value T) (PointEdit, error) {
+	if tick < 0 {
+		return PointEdit{}, ErrPointOutOfRange
+	}
+
+	index := len(e.Values)
+	start := 0
+	for i, p := range e.Values {
+		if start == tick {
+			return PointEdit{}, ErrPointExists
+		}
+		if start > tick {
+			index = i
+			break
+		}
+		start += p.Length()
+	}
+
+	var ticks int
+	if index < len(e.Values) {
+		// split the previous segment
+		prev := &e.Values[index-1]
+		prevStart := start - prev.Length()
+		ticks = prev.Ticks - (tick - prevStart)
+		prev.Ticks = tick - prevStart
+	} else if index > 0 {
+		// extend the last point out to the new one
+		prev := &e.Values[index-1]
+		prev.Ticks = tick - (start - prev.Length())
+	}
+
+	e.Values = append(e.Values, EnvPoint[T]{})
+	copy(e.Values[index+1:], e.Values[index:])
+	e.Values[index] = EnvPoint[T]{
+		Ticks: ticks,
+		Y:     value,
+	}
+
+	edit := PointEdit{
+		Index: index,
+		Shift: 1,
+	}
+	e.Loop = shiftLoop(e.Loop, edit)
+	e.Sustain = shiftLoop(e.Sustain, edit)
+	return edit, nil
+}
+
+// DeletePoint removes the point, merging its segment into the previous one so that the other
+// points keep their timing. The exception is the first point, which has no previous one: the
+// second point becomes the first, at tick 0, so every other point moves earlier by the length of
+// the deleted one. The loop and sustain ranges are adjusted to keep covering the same points; a
+// range that no longer covers any points is disabled.
+func (e *Envelope[T]) DeletePoint(index int) (PointEdit, error) {
+	if index < 0 || index >= len(e.Values) {
+		return PointEdit{}, ErrPointOutOfRange
+	}
+
+	if index > 0 && index < len(e.Values)-1 {
+		e.Values[index-1].Ticks += e.Values[index].Ticks
+	}
+	e.Values = append(e.Values[:index], e.Values[index+1:]...)
+
+	edit := PointEdit{
+		Index: index,
+		Shift: -1,
+	}
+	e.Loop = shiftLoop(e.Loop, edit)
+	e.Sustain = shiftLoop(e.Sustain, edit)
+	return edit, nil
+}
+
+// MovePoint moves the point to the tick (from the start of the envelope) and sets its value.
+// The tick is limited to lie between the neighboring points, and the first point always stays at 0.
+func (e *Envelope[T]) MovePoint(index int, tick int, value T) (PointEdit, error) {
+	start, err := e.PointTick(index)
+	if err != nil {
+		return PointEdit{}, err
+	}
+
+	if index > 0 {
+		prev := &e.Values[index-1]
+		prevStart := start - prev.Length()
+		lo := prevStart + 1
+		hi := tick
+		if index < len(e.Values)-1 {
+			hi = start + e.Values[index].Length() - 1
+		}
+		switch {
+		case tick < lo:
+			tick = lo
+		case tick > hi:
+			tick = hi
+		}
+
+		if index < len(e.Values)-1 {
+			e.Values[index].Ticks -= tick - start
+		}
+		prev.Ticks = tick - prevStart
+	}
+	e.Values[index].Y = value
+
+	return PointEdit{
+		Index: index,
+	}, nil
+}
+
+// SetLoop replaces the loop with one of the mode and settings (in point indices), after checking
+// that the settings fit within the points
+func (e *Envelope[T]) SetLoop(mode loop.Mode, settings loop.Settings) error {
+	l, err := loop.NewValidatedLoop(mode, settings, len(e.Values))
+	if err != nil {
+		return err
+	}
+	e.Loop = l
+	return nil
+}
+
+// SetSustain replaces the sustain with one of the mode and settings (in point indices), after
+// checking that the settings fit within the points
+func (e *Envelope[T]) SetSustain(mode loop.Mode, settings loop.Settings) error {
+	l, err := loop.NewValidatedLoop(mode, settings, len(e.Values))
+	if err != nil {
+		return err
+	}
+	e.Sustain = l
+	return nil
+}
+
+// MapValues replaces the value of every point with the result of `fn`
+func (e *Envelope[T]) MapValues(fn func(T) T) {
+	for i := range e.Values {
+		e.Values[i].Y = fn(e.Values[i].Y)
+	}
+}
+
+// ClampValues limits the value of every point of the envelope to the range [min, max]
+func ClampValues[T Ordered](env *Envelope[T], min T, max T) {
+	env.MapValues(func(v T) T {
+		switch {
+		case v < min:
+			return min
+		case v > max:
+			return max
+		}
+		return v
+	})
+}
+
+// shiftLoop adjusts the range of the loop for a point being inserted or deleted
+func shiftLoop(l loop.Loop, edit PointEdit) loop.Loop {
+	se, ok := l.(loop.SettingsEditor)
+	if !ok || edit.Shift == 0 {
+		return l
+	}
+
+	s := se.GetSettings()
+	wasEmpty := s.Begin == s.End
+	shift := func(v int, inclusive bool) int {
+		if v > edit.Index || (inclusive && v == edit.Index) {
+			return v + edit.Shift
+		}
+		return v
+	}
+
+	if edit.Shift > 0 {
+		s.Begin = shift(s.Begin, true)
+		s.End = shift(s.End, false)
+	} else {
+		s.Begin = shift(s.Begin, false)
+		s.End = shift(s.End, false)
+		if s.Begin > s.End {
+			s.Begin = s.End
+		}
+	}
+
+	if !wasEmpty && s.Begin == s.End && l.Enabled() {
+		return &loop.Disabled{}
+	}
+	se.SetSettings(s)
+	return l
+}
+
+// Edit applies the edit to the envelope that the state is playing, then moves the state so that it
+// carries on from the same point (and the same place within it) instead of starting over, keeping
+// its place within any loop (such as the direction of a ping-pong loop, or the iterations of a
+// counted loop). If the point being played was deleted, the state carries on from the point that
+// its segment was merged into, or from the start of the new first point if it was the first.
+func (e *State[T]) Edit(keyOn bool, edit func(env *Envelope[T]) (PointEdit, error)) error {
+	if e.env == nil {
+		return ErrEmptyEnvelope
+	}
+
+	if e.env.FT2 {
+		pe, err := edit(e.env)
+		if err != nil || e.stopped {
+			return err
+		}
+		if len(e.env.Values) == 0 {
+			e.stopped = true
+			return nil
+		}
+		e.ft2Edit(pe)
+		return nil
+	}
+
+	cur, _, _ := e.calcLoopedPosAt(e.position, keyOn)
+	phase := e.loopPhase(keyOn)
+	holding := e.holding(keyOn)
+	var played int
+	if cur < len(e.env.Values) {
+		if played = e.env.Values[cur].Length() - e.length; played < 0 {
+			played = 0
+		}
+	}
+	pe, err := edit(e.env)
+	if err != nil || e.stopped {
+		return err
+	}
+	if len(e.env.Values) == 0 {
+		e.stopped = true
+		return nil
+	}
+	if pe.Shift == 0 {
+		// the points are where they were, so only the current point's length may have changed
+		e.resume(cur, played, holding, keyOn)
+		return nil
+	}
+
+	want, base := cur, e.position
+	if pe.Index <= cur {
+		want += pe.Shift
+		base += pe.Shift
+	}
+	switch {
+	case want < 0:
+		// the first point was deleted
+		want, base = 0, 0
+		played = 0
+		e.elapsed = 0
+	case pe.Shift < 0 && pe.Index == cur:
+		// the current point was deleted, so its segment was merged into the previous one
+		// (unless it was the last point, which leaves the previous one played through)
+		played = e.env.Values[want].Length()
+		if cur < len(e.env.Values) {
+			played -= e.length
+		}
+	}
+	if want >= len(e.env.Values) {
+		e.stopped = true
+		return nil
+	}
+
+	// the loop may have grown or shrunk along with the points, so look for the nearest position
+	// that lands on the same point at the same stage of the loop
+	e.position = e.findPosition(base, want, phase, keyOn)
+	e.resume(want, played, holding, keyOn)
+	return nil
+}
+
+// resume sets the state to `played` ticks into the point, moving on past it when the point is no
+// longer that long. A state that was holding on a zero-length loop only has its length limited.
+func (e *State[T]) resume(point int, played int, holding bool, keyOn bool) {
+	ticks := e.env.Values[point].Length()
+	if holding || e.holding(keyOn) {
+		if e.length > ticks {
+			e.length = ticks
+		}
+		return
+	}
+
+	e.length = ticks - played
+	if e.length > 0 {
+		return
+	}
+	over := -e.length
+	if done, _ := e.nextSegment(keyOn, keyOn); done {
+		return
+	}
+	for i := 0; i < over; i++ {
+		if e.Advance(keyOn, keyOn) {
+			return
+		}
+	}
+}
+
+// findPosition returns the (unlooped) position nearest to `base` that lands on the point, preferring
+// one at the loop phase given
+func (e *State[T]) findPosition(base int, point int, phase int, keyOn bool) int {
+	nearest := -1
+	for d := 0; d <= 2*len(e.env.Values)+2; d++ {
+		for _, p := range [2]int{base - d, base + d} {
+			if p < 0 {
+				continue
+			}
+			if c, _, _ := e.calcLoopedPosAt(p, keyOn); c != point {
+				continue
+			}
+			e.position = p
+			if e.loopPhase(keyOn) == phase {
+				return p
+			}
+			if nearest < 0 {
+				nearest = p
+			}
+		}
+	}
+	if nearest < 0 {
+		return point
+	}
+	return nearest
+}
@@ -0,0 +1,185 @@
+package envelope
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gotracker/voice/loop"
+)
+
+func newEditEnvelope(l loop.Loop, ticks ...int) *Envelope[int] {
+	env := &Envelope[int]{
+		Enabled: true,
+		Loop:    l,
+		Sustain: &loop.Disabled{},
+	}
+	for i, t := range ticks {
+		env.Values = append(env.Values, EnvPoint[int]{
+			Ticks:         t,
+			Y:             i * 10,
+			Interpolation: InterpolationStep,
+		})
+	}
+	return env
+}
+
+// playValues returns the values of the points played over the next `n` ticks
+func playValues(s *State[int], n int) []int {
+	var out []int
+	for i := 0; i < n; i++ {
+		if cur, _, _ := s.GetCurrentValue(false); cur != nil {
+			out = append(out, cur.Y)
+		} else {
+			out = append(out, -1)
+		}
+		s.Advance(false, false)
+	}
+	return out
+}
+
+func TestEditMovePointKeepsLoopPlace(t *testing.T) {
+	ticks := []int{2, 2, 2, 2, 2, 2}
+	for _, ep := range []loop.PingPongEndpoints{
+		loop.PingPongDuplicateBoth,
+		loop.PingPongDuplicateNone,
+		loop.PingPongDuplicateBegin,
+		loop.PingPongDuplicateEnd,
+	} {
+		for skip := 0; skip < 30; skip++ {
+			newLoop := func() loop.Loop {
+				return &loop.PingPong{
+					Settings:  loop.Settings{Begin: 1, End: 4},
+					Endpoints: ep,
+				}
+			}
+			want := newEditEnvelope(newLoop(), ticks...)
+			var ref State[int]
+			ref.Reset(want)
+			playValues(&ref, skip)
+
+			env := newEditEnvelope(newLoop(), ticks...)
+			var s State[int]
+			s.Reset(env)
+			playValues(&s, skip)
+			err := s.Edit(false, func(env *Envelope[int]) (PointEdit, error) {
+				tick, _ := env.PointTick(4)
+				return env.MovePoint(4, tick, 40)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got, exp := playValues(&s, 20), playValues(&ref, 20); fmt.Sprint(got) != fmt.Sprint(exp) {
+				t.Errorf("endpoints %v, after %d ticks: got %v, want %v", ep, skip, got, exp)
+			}
+		}
+	}
+}
+
+func TestEditInsertKeepsPlace(t *testing.T) {
+	loops := []struct {
+		name string
+		make func() loop.Loop
+	}{
+		{"disabled", func() loop.Loop { return &loop.Disabled{} }},
+		{"normal", func() loop.Loop { return &loop.Normal{Settings: loop.Settings{Begin: 1, End: 4}} }},
+		{"counted", func() loop.Loop {
+			return &loop.Counted{Settings: loop.Settings{Begin: 1, End: 4}, Count: 2}
+		}},
+	}
+	for _, lk := range loops {
+		// inserting a point splits a segment, so the timing of everything else is unchanged,
+		// and the edited state should match one that played the edited envelope from the start
+		// (tick 7 is left out, as it splits the last looped point and shortens the loop)
+		for _, tick := range []int{1, 3, 5, 9} {
+			for skip := 0; skip < 24; skip++ {
+				env := newEditEnvelope(lk.make(), 2, 2, 2, 2, 2, 2)
+				var s State[int]
+				s.Reset(env)
+				playValues(&s, skip)
+				err := s.Edit(false, func(env *Envelope[int]) (PointEdit, error) {
+					return env.InsertPoint(tick, 99)
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				want := newEditEnvelope(lk.make(), 2, 2, 2, 2, 2, 2)
+				if _, err := want.InsertPoint(tick, 99); err != nil {
+					t.Fatal(err)
+				}
+				var ref State[int]
+				ref.Reset(want)
+				playValues(&ref, skip)
+
+				if got, exp := playValues(&s, 24), playValues(&ref, 24); fmt.Sprint(got) != fmt.Sprint(exp) {
+					t.Errorf("%s: insert at tick %d after %d ticks: got %v, want %v", lk.name, tick, skip, got, exp)
+				}
+			}
+		}
+	}
+}
+
+func TestEditDeletePoint(t *testing.T) {
+	tests := []struct {
+		name   string
+		skip   int
+		delete int
+		want   []int
+	}{
+		{"before current", 5, 1, []int{20, 30, 30, 40, 40}},
+		{"current merges into previous", 5, 2, []int{10, 30, 30, 40, 40}},
+		{"first restarts new first", 1, 0, []int{10, 10, 20, 20, 30}},
+		{"after current", 1, 2, []int{0, 10, 10, 10, 10}},
+	}
+	for _, tc := range tests {
+		env := newEditEnvelope(&loop.Disabled{}, 2, 2, 2, 2, 2)
+		var s State[int]
+		s.Reset(env)
+		playValues(&s, tc.skip)
+		err := s.Edit(false, func(env *Envelope[int]) (PointEdit, error) {
+			return env.DeletePoint(tc.delete)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := playValues(&s, len(tc.want)); fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestEditDeletePointTiming(t *testing.T) {
+	tests := []struct {
+		name   string
+		delete int
+		want   []int // ticks of the remaining points
+	}{
+		{"first moves the others earlier", 0, []int{0, 3, 7}},
+		{"middle keeps the others", 1, []int{0, 5, 9}},
+		{"last keeps the others", 3, []int{0, 2, 5}},
+	}
+	for _, tc := range tests {
+		env := newEditEnvelope(&loop.Disabled{}, 2, 3, 4, 1)
+		if _, err := env.DeletePoint(tc.delete); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		var got []int
+		for i := range env.Values {
+			tick, err := env.PointTick(i)
+			if err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+			got = append(got, tick)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	env := newEditEnvelope(&loop.Disabled{}, 2)
+	if _, err := env.DeletePoint(1); !errors.Is(err, ErrPointOutOfRange) {
+		t.Errorf("out of range: got %v, want %v", err, ErrPointOutOfRange)
+	}
+}
@@ -1,6 +1,8 @@
 package envelope
 
 import (
+	"time"
+
 	"github.com/gotracker/voice/loop"
 )
 
@@ -8,8 +10,14 @@ import (
 type State[T any] struct {
 	position int
 	length   int
+	elapsed  time.Duration // time accumulated towards the next unit (time-based envelopes only)
 	stopped  bool
 	env      *Envelope[T]
+
+	// FT2 envelopes only
+	ft2Tick   int  // tick counter along the envelope's timeline
+	ft2Base   int  // point that the value starts from
+	ft2Interp bool // true if the value is moving from the base point towards the position
 }
 
 // Stopped returns true if the envelope state is stopped
@@ -35,13 +43,45 @@ func (e *State[T]) Reset(env *Envelope[T]) {
 		return
 	}
 
+	e.stopped = false
 	e.position = 0
+	e.elapsed = 0
+	if e.env.FT2 {
+		if len(e.env.Values) == 0 {
+			e.stopped = true
+			return
+		}
+		e.ft2Reset()
+		return
+	}
 	pos, _, _ := e.calcLoopedPos(true)
 	if pos < len(e.env.Values) {
 		e.length = e.env.Values[pos].Length()
 	}
 }
 
+// Carry resets the envelope, then continues on from the position of the `prev` state if the
+// envelope has its carry flag set. A `prev` state that is missing, stopped, or playing a different
+// envelope is not carried. `prev` may be the state itself (e.g. when a channel reuses its voice).
+func (e *State[T]) Carry(prev *State[T], env *Envelope[T]) {
+	var carried State[T]
+	if prev != nil {
+		carried = *prev
+	}
+
+	e.Reset(env)
+	if e.stopped || !env.Carry || carried.env != env || carried.stopped {
+		return
+	}
+
+	e.position = carried.position
+	e.length = carried.length
+	e.elapsed = carried.elapsed
+	e.ft2Tick = carried.ft2Tick
+	e.ft2Base = carried.ft2Base
+	e.ft2Interp = carried.ft2Interp
+}
+
 func (e *State[T]) calcLoopedPos(keyOn bool) (int, int, bool) {
 	nPoints := len(e.env.Values)
 	var looped bool
@@ -53,12 +93,26 @@ func (e *State[T]) calcLoopedPos(keyOn bool) (int, int, bool) {
 	return cur, next, looped
 }
 
+// calcLoopedPosAt returns the looped point for the position and the one following it, along with
+// whether the position is actually within a loop
+func (e *State[T]) calcLoopedPosAt(position int, keyOn bool) (int, int, bool) {
+	nPoints := len(e.env.Values)
+	cur, looped := loop.CalcLoopPos(e.env.Loop, e.env.Sustain, position, nPoints, keyOn)
+	next, _ := loop.CalcLoopPos(e.env.Loop, e.env.Sustain, position+1, nPoints, keyOn)
+	return cur, next, looped
+}
+
 // GetCurrentValue returns the current value
 func (e *State[T]) GetCurrentValue(keyOn bool) (*EnvPoint[T], *EnvPoint[T], float32) {
 	if e.stopped {
 		return nil, nil, 0
 	}
 
+	if e.env.FT2 {
+		cur, next, t := e.ft2Value()
+		return cur, next, cur.InterpolationFor(e.env).Shape(t)
+	}
+
 	pos, npos, looped := e.calcLoopedPos(keyOn)
 	if pos >= len(e.env.Values) {
 		return nil, nil, 0
@@ -70,26 +124,31 @@ func (e *State[T]) GetCurrentValue(keyOn bool) (*EnvPoint[T], *EnvPoint[T], floa
 
 	cur := e.env.Values[pos]
 	next := e.env.Values[npos]
-	t := float32(0)
+	t := e.segmentFraction(cur, keyOn, looped)
+	t = cur.InterpolationFor(e.env).Shape(t)
+	return &cur, &next, t
+}
+
+// segmentFraction returns how far (0 through 1) the state is through the segment starting at `cur`
+func (e *State[T]) segmentFraction(cur EnvPoint[T], keyOn bool, looped bool) float32 {
 	tl := cur.Length()
-	if tl > 0 {
-		l := float32(e.length)
-		if looped {
-			if e.env.Sustain.Enabled() && keyOn && e.env.Sustain.Length() == 0 {
-				l = 0
-			} else {
-				l = float32(e.length)
-			}
+	if tl <= 0 {
+		return 0
+	}
+
+	l := float32(e.length)
+	if looped {
+		if e.env.Sustain.Enabled() && keyOn && e.env.Sustain.Length() == 0 {
+			l = 0
+		} else {
+			l = float32(e.length)
 		}
-		t = 1 - (l / float32(tl))
 	}
-	switch {
-	case t < 0:
-		t = 0
-	case t > 1:
-		t = 1
+	if e.env.TimeBase > 0 && l > 0 {
+		// include the progress made towards the next unit
+		l -= float32(e.elapsed) / float32(e.env.TimeBase)
 	}
-	return &cur, &next, t
+	return 1 - (l / float32(tl))
 }
 
 // Advance advances the state by 1 tick
@@ -97,37 +156,217 @@ func (e *State[T]) Advance(keyOn bool, prevKeyOn bool) bool {
 	if e.stopped {
 		return false
 	}
+	if e.env.FT2 {
+		return e.ft2Advance(keyOn, prevKeyOn)
+	}
+	if e.holding(keyOn) {
+		return false
+	}
 
-	if e.env.Sustain.Enabled() && keyOn {
-		if e.env.Sustain.Length() == 0 {
+	e.length--
+	if e.length > 0 {
+		return false
+	}
+	done, _ := e.nextSegment(keyOn, prevKeyOn)
+	return done
+}
+
+// AdvanceTime advances the state by the elapsed time when the envelope is time-based, otherwise
+// it advances the state by 1 tick, the same as Advance. Time that does not make up a whole unit
+// of the envelope's time base is carried over to the next call.
+func (e *State[T]) AdvanceTime(keyOn bool, prevKeyOn bool, elapsed time.Duration) bool {
+	if e.stopped {
+		return false
+	}
+	if e.env.TimeBase <= 0 {
+		return e.Advance(keyOn, prevKeyOn)
+	}
+
+	e.elapsed += elapsed
+	units := int(e.elapsed / e.env.TimeBase)
+	e.elapsed -= time.Duration(units) * e.env.TimeBase
+	for i := 0; i < units; i++ {
+		if done := e.Advance(keyOn, prevKeyOn); done {
+			return true
+		}
+		// the key-on change only happens on the first unit
+		prevKeyOn = keyOn
+	}
+	return false
+}
+
+// Seek resets the state and moves it forward by `ticks` ticks, as though Advance had been called
+// that many times with the same key-on states, returning true if the envelope finished on the way.
+// Whole passes through an active loop are skipped over rather than stepped through, so the cost
+// depends on the number of points in the envelope instead of the position being sought.
+// FT2 envelopes are positioned the way FT2's envelope position effect (Lxx) does it instead.
+// For a time-based envelope, `ticks` is in units of its time base rather than in ticks, and the
+// state lands exactly on a unit (any progress towards the next unit is cleared); use SeekTime to
+// position it by time instead.
+func (e *State[T]) Seek(ticks int, keyOn bool, prevKeyOn bool) bool {
+	e.Reset(e.env)
+	if !e.stopped && e.env.FT2 {
+		return e.ft2Seek(ticks, keyOn)
+	}
+	if e.stopped || ticks <= 0 || e.holding(keyOn) {
+		return false
+	}
+
+	// when releasing, the position is rebased on every segment, so the position alone determines
+	// what follows. otherwise, the looped point (and the one after it) determine it.
+	rebasing := keyOn != prevKeyOn && prevKeyOn
+	type visit struct {
+		position int
+		ticks    int
+	}
+	seen := make(map[[2]int]visit)
+
+	for {
+		step := e.length
+		if step < 1 {
+			step = 1
+		}
+		if ticks < step {
+			e.length -= ticks
 			return false
 		}
-	} else if e.env.Loop.Enabled() {
-		if e.env.Loop.Length() == 0 {
+		ticks -= step
+		done, stuck := e.nextSegment(keyOn, prevKeyOn)
+		if done {
+			return true
+		}
+		if stuck {
+			// nothing will change from here on
+			e.length = 1
+			return false
+		}
+		if ticks == 0 {
 			return false
 		}
-	}
 
-loopAdvance:
-	e.length--
-	if e.length > 0 {
-		return false
+		cur, next, inLoop := e.calcLoopedPosAt(e.position, keyOn)
+		if !inLoop {
+			continue
+		}
+		key := [2]int{cur, next}
+		if rebasing {
+			key = [2]int{e.position, -1}
+		}
+		v, ok := seen[key]
+		if !ok {
+			seen[key] = visit{
+				position: e.position,
+				ticks:    ticks,
+			}
+			continue
+		}
+
+		cycleTicks := v.ticks - ticks
+		if cycleTicks <= 0 {
+			continue
+		}
+		if rebasing {
+			ticks %= cycleTicks
+		} else if cycles := ticks / cycleTicks; cycles > 0 {
+			// only skip when the loop really does repeat (e.g. it isn't about to run out of iterations)
+			target := e.position + cycles*(e.position-v.position)
+			if tc, tn, tl := e.calcLoopedPosAt(target, keyOn); tl && tc == cur && tn == next {
+				e.position = target
+				ticks -= cycles * cycleTicks
+			}
+		}
+		seen = make(map[[2]int]visit)
 	}
-	if keyOn != prevKeyOn && prevKeyOn {
-		p, _, _ := e.calcLoopedPos(prevKeyOn)
-		e.position = p
+}
+
+// SeekTime resets the state and moves it forward by the elapsed time, as though AdvanceTime had been
+// called with the same key-on states until that much time had passed. For a time-based envelope, time
+// that does not make up a whole unit of its time base is kept as progress towards the next unit. For
+// an envelope that is not time-based, the time is converted to whole ticks of `tickDuration`.
+func (e *State[T]) SeekTime(elapsed time.Duration, tickDuration time.Duration, keyOn bool, prevKeyOn bool) bool {
+	if e.env == nil || e.env.TimeBase <= 0 {
+		var ticks int
+		if tickDuration > 0 {
+			ticks = int(elapsed / tickDuration)
+		}
+		return e.Seek(ticks, keyOn, prevKeyOn)
 	}
 
-	e.position++
-	pos, _, _ := e.calcLoopedPos(keyOn)
-	if pos >= len(e.env.Values) {
-		e.stopped = true
-		return true
+	units := int(elapsed / e.env.TimeBase)
+	done := e.Seek(units, keyOn, prevKeyOn)
+	if !done && !e.stopped && !e.env.FT2 {
+		e.elapsed = elapsed - time.Duration(units)*e.env.TimeBase
 	}
+	return done
+}
 
-	e.length = e.env.Values[pos].Length()
-	if e.length <= 0 {
-		goto loopAdvance
+// holding returns true if the envelope is held on a zero-length sustain or loop
+func (e *State[T]) holding(keyOn bool) bool {
+	if e.env.Sustain.Enabled() && keyOn {
+		return e.env.Sustain.Length() == 0
+	} else if e.env.Loop.Enabled() {
+		return e.env.Loop.Length() == 0
 	}
 	return false
 }
+
+// nextSegment moves the state on to the next point, skipping over any points that have no length.
+// It returns true if the envelope has finished. If only points with no length can be reached (which
+// would otherwise spin forever), the state is held where it was and the stuck flag is returned.
+func (e *State[T]) nextSegment(keyOn bool, prevKeyOn bool) (bool, bool) {
+	startPos := e.position
+	rebasing := keyOn != prevKeyOn && prevKeyOn
+	// only zero-length points are reachable when the same looped point comes around again at the
+	// same stage of the loop, so remember the ones passed over
+	var seen map[[3]int]struct{}
+	for {
+		if rebasing {
+			p, _, _ := e.calcLoopedPos(prevKeyOn)
+			e.position = p
+		}
+
+		e.position++
+		pos, next, _ := e.calcLoopedPos(keyOn)
+		if pos >= len(e.env.Values) {
+			e.stopped = true
+			return true, false
+		}
+
+		e.length = e.env.Values[pos].Length()
+		if e.length > 0 {
+			return false, false
+		}
+
+		key := [3]int{pos, next, e.loopPhase(keyOn)}
+		if rebasing {
+			key = [3]int{e.position, -1, 0}
+		}
+		if _, ok := seen[key]; ok {
+			break
+		}
+		if seen == nil {
+			seen = make(map[[3]int]struct{})
+		}
+		seen[key] = struct{}{}
+	}
+
+	e.position = startPos
+	e.length = 1
+	return false, true
+}
+
+// loopPhase returns how far the active loop has got, for loops where that changes what follows:
+// the number of completed iterations of a counted loop, or the direction of a ping-pong loop
+func (e *State[T]) loopPhase(keyOn bool) int {
+	l := e.env.Loop
+	if keyOn && e.env.Sustain.Enabled() {
+		l = e.env.Sustain
+	}
+	switch lp := l.(type) {
+	case *loop.Counted:
+		return lp.CalcIteration(e.position)
+	case *loop.PingPong:
+		return lp.CalcIteration(e.position) % 2
+	}
+	return 0
+}
@@ -0,0 +1,259 @@
+package envelope
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/gotracker/voice/loop"
+)
+
+// testLoopKinds builds each kind of loop that an envelope can use, over the range given
+var testLoopKinds = []struct {
+	name string
+	make func(s loop.Settings, r *rand.Rand) loop.Loop
+}{
+	{"disabled", func(s loop.Settings, r *rand.Rand) loop.Loop { return &loop.Disabled{} }},
+	{"normal", func(s loop.Settings, r *rand.Rand) loop.Loop { return &loop.Normal{Settings: s} }},
+	{"legacy", func(s loop.Settings, r *rand.Rand) loop.Loop { return &loop.Legacy{Settings: s} }},
+	{"pingpong-both", func(s loop.Settings, r *rand.Rand) loop.Loop {
+		return &loop.PingPong{Settings: s, Endpoints: loop.PingPongDuplicateBoth}
+	}},
+	{"pingpong-none", func(s loop.Settings, r *rand.Rand) loop.Loop {
+		return &loop.PingPong{Settings: s, Endpoints: loop.PingPongDuplicateNone}
+	}},
+	{"pingpong-begin", func(s loop.Settings, r *rand.Rand) loop.Loop {
+		return &loop.PingPong{Settings: s, Endpoints: loop.PingPongDuplicateBegin}
+	}},
+	{"pingpong-end", func(s loop.Settings, r *rand.Rand) loop.Loop {
+		return &loop.PingPong{Settings: s, Endpoints: loop.PingPongDuplicateEnd}
+	}},
+	{"counted", func(s loop.Settings, r *rand.Rand) loop.Loop {
+		return &loop.Counted{Settings: s, Count: r.Intn(4)}
+	}},
+}
+
+// testKeyStates are the key-on states that Advance and Seek are called with
+var testKeyStates = []struct {
+	name             string
+	keyOn, prevKeyOn bool
+}{
+	{"held", true, true},
+	{"released", false, false},
+	{"releasing", false, true},
+	{"pressing", true, false},
+}
+
+func randSettings(r *rand.Rand, n int) loop.Settings {
+	b := r.Intn(n)
+	return loop.Settings{
+		Begin: b,
+		End:   b + r.Intn(n-b+1),
+	}
+}
+
+func randEnvelope(r *rand.Rand, loopKind, sustainKind int) *Envelope[int] {
+	n := 1 + r.Intn(7)
+	env := &Envelope[int]{
+		Enabled: true,
+		Loop:    testLoopKinds[loopKind].make(randSettings(r, n), r),
+		Sustain: testLoopKinds[sustainKind].make(randSettings(r, n), r),
+	}
+	for i := 0; i < n; i++ {
+		ticks := 1 + r.Intn(6)
+		if r.Intn(4) == 0 {
+			ticks = 0
+		}
+		env.Values = append(env.Values, EnvPoint[int]{
+			Ticks: ticks,
+			Y:     r.Intn(64),
+		})
+	}
+	return env
+}
+
+func TestSeekMatchesAdvance(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for li, lk := range testLoopKinds {
+		for si, sk := range testLoopKinds {
+			for _, ks := range testKeyStates {
+				name := fmt.Sprintf("loop=%s/sustain=%s/%s", lk.name, sk.name, ks.name)
+				for iter := 0; iter < 60; iter++ {
+					env := randEnvelope(r, li, si)
+					ticks := r.Intn(300)
+					if iter%10 == 0 {
+						ticks = r.Intn(20000)
+					}
+
+					var a State[int]
+					a.Reset(env)
+					aDone := false
+					for i := 0; i < ticks && !aDone; i++ {
+						aDone = a.Advance(ks.keyOn, ks.prevKeyOn)
+					}
+
+					var b State[int]
+					b.Reset(env)
+					bDone := b.Seek(ticks, ks.keyOn, ks.prevKeyOn)
+
+					ac, an, at := a.GetCurrentValue(ks.keyOn)
+					bc, bn, bt := b.GetCurrentValue(ks.keyOn)
+					same := aDone == bDone && a.Stopped() == b.Stopped() && at == bt && (ac == nil) == (bc == nil)
+					if same && ac != nil {
+						same = *ac == *bc && *an == *bn && a.length == b.length
+					}
+					if !same {
+						t.Fatalf("%s: seek to %d differs from advancing\nloop=%+v sustain=%+v points=%+v\nadvanced=%+v\nsought=%+v",
+							name, ticks, env.Loop, env.Sustain, env.Values, a, b)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestCarry(t *testing.T) {
+	newEnv := func(carry bool) *Envelope[int] {
+		return &Envelope[int]{
+			Enabled: true,
+			Carry:   carry,
+			Loop:    &loop.Disabled{},
+			Sustain: &loop.Disabled{},
+			Values:  []EnvPoint[int]{{Ticks: 5, Y: 0}, {Ticks: 5, Y: 10}, {Ticks: 0, Y: 20}},
+		}
+	}
+	carryEnv := newEnv(true)
+
+	tests := []struct {
+		name    string
+		env     *Envelope[int]
+		prevEnv *Envelope[int]
+		self    bool // carry from the state itself
+		stop    bool // stop the previous state
+		wantPos int
+		wantLen int
+	}{
+		{name: "from another state", env: carryEnv, prevEnv: carryEnv, wantPos: 1, wantLen: 3},
+		{name: "from itself", env: carryEnv, prevEnv: carryEnv, self: true, wantPos: 1, wantLen: 3},
+		{name: "carry flag not set", env: newEnv(false), wantPos: 0, wantLen: 5},
+		{name: "different envelope", env: carryEnv, prevEnv: newEnv(true), wantPos: 0, wantLen: 5},
+		{name: "previous stopped", env: carryEnv, prevEnv: carryEnv, stop: true, wantPos: 0, wantLen: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prevEnv := tt.prevEnv
+			if prevEnv == nil {
+				prevEnv = tt.env
+			}
+			var prev State[int]
+			prev.Reset(prevEnv)
+			prev.Seek(7, true, true)
+			if tt.stop {
+				prev.Stop()
+			}
+
+			s := &State[int]{}
+			if tt.self {
+				s = &prev
+			}
+			s.Carry(&prev, tt.env)
+			if s.position != tt.wantPos || s.length != tt.wantLen {
+				t.Errorf("got position %d, length %d; want %d, %d", s.position, s.length, tt.wantPos, tt.wantLen)
+			}
+			if s.Stopped() {
+				t.Error("carried state is stopped")
+			}
+		})
+	}
+
+	var s State[int]
+	s.Carry(nil, carryEnv)
+	if s.position != 0 || s.length != 5 {
+		t.Errorf("nil previous state: got position %d, length %d", s.position, s.length)
+	}
+}
+
+func TestSeekTimeMatchesAdvanceTime(t *testing.T) {
+	env := &Envelope[int]{
+		Enabled:  true,
+		Loop:     &loop.Normal{Settings: loop.Settings{Begin: 1, End: 3}},
+		Sustain:  &loop.Disabled{},
+		Values:   []EnvPoint[int]{{Ticks: 5, Y: 0}, {Ticks: 4, Y: 40}, {Ticks: 3, Y: 10}, {Ticks: 0, Y: 0}},
+		TimeBase: 2 * time.Millisecond,
+	}
+	const step = 3 * time.Millisecond
+
+	var a State[int]
+	a.Reset(env)
+	for i := 1; i <= 40; i++ {
+		a.AdvanceTime(true, true, step)
+
+		var b State[int]
+		b.Reset(env)
+		b.SeekTime(time.Duration(i)*step, 0, true, true)
+
+		ac, an, at := a.GetCurrentValue(true)
+		bc, bn, bt := b.GetCurrentValue(true)
+		if *ac != *bc || *an != *bn || at != bt || a.elapsed != b.elapsed {
+			t.Fatalf("after %v: sought state %+v differs from advanced state %+v", time.Duration(i)*step, b, a)
+		}
+	}
+}
+
+func TestSeekTimeConvertsTicks(t *testing.T) {
+	env := &Envelope[int]{
+		Enabled: true,
+		Loop:    &loop.Disabled{},
+		Sustain: &loop.Disabled{},
+		Values:  []EnvPoint[int]{{Ticks: 5, Y: 0}, {Ticks: 5, Y: 10}, {Ticks: 0, Y: 20}},
+	}
+	var s State[int]
+	s.Reset(env)
+	s.SeekTime(7*20*time.Millisecond+5*time.Millisecond, 20*time.Millisecond, true, true)
+	if s.position != 1 || s.length != 3 {
+		t.Errorf("got position %d, length %d; want 1, 3", s.position, s.length)
+	}
+}
+
+func TestCountedLoopOfZeroLengthPoints(t *testing.T) {
+	env := &Envelope[int]{
+		Enabled: true,
+		Loop: &loop.Counted{
+			Settings: loop.Settings{Begin: 1, End: 3},
+			Count:    10,
+		},
+		Sustain: &loop.Disabled{},
+	}
+	for i, ticks := range []int{5, 0, 0, 3, 1} {
+		env.Values = append(env.Values, EnvPoint[int]{
+			Ticks: ticks,
+			Y:     i,
+		})
+	}
+
+	var s State[int]
+	s.Reset(env)
+	var got []int
+	for i := 0; i < 10; i++ {
+		cur, _, _ := s.GetCurrentValue(false)
+		if cur == nil {
+			got = append(got, -1)
+		} else {
+			got = append(got, cur.Y)
+		}
+		s.Advance(false, false)
+	}
+	want := []int{0, 0, 0, 0, 0, 3, 3, 3, 4, -1}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got points %v, want %v", got, want)
+	}
+
+	var sought State[int]
+	sought.Reset(env)
+	sought.Seek(6, false, false)
+	if cur, _, _ := sought.GetCurrentValue(false); cur == nil || cur.Y != 3 {
+		t.Fatalf("seeking 6 ticks landed on %v, want point 3", cur)
+	}
+}
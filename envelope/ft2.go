@@ -0,0 +1,205 @@
+package envelope
+
+import "github.com/gotracker/voice/loop"
+
+// FastTracker 2 envelopes are driven by a tick counter that runs along the envelope's timeline,
+// checked against the tick of the point being headed towards (the state's position). When the
+// counter reaches that point, the point's value is taken and the next point is headed towards,
+// unless one of the following applies:
+//   - the point is the loop end, in which case the counter jumps back to the loop begin point and
+//     heads towards the point after it. When the loop end is also the sustain point, this only
+//     happens while the key is on; after key-off, the envelope carries on past the loop end.
+//   - the point that was reached is the sustain point and the key is on, in which case the value
+//     stays at the point and the counter runs on without anything further being reached
+//   - there are no more points, in which case the value stays at the point reached
+//
+// On key-off, a counter that has run past the point being headed towards is stepped back to
+// just before it, so a held sustain point is reached again and the envelope carries on from there.
+
+// ft2Reset resets the state the way FT2 does when an instrument is triggered, including the
+// first update (which reaches the first point)
+func (e *State[T]) ft2Reset() {
+	e.position = 0
+	e.ft2Tick = -1
+	e.ft2Base = 0
+	e.ft2Interp = false
+	e.ft2Update(true)
+}
+
+// ft2Advance advances the state by 1 tick the way FT2 does
+func (e *State[T]) ft2Advance(keyOn bool, prevKeyOn bool) bool {
+	if keyOn != prevKeyOn && prevKeyOn {
+		if x := e.ft2PointTick(e.position); e.ft2Tick >= x {
+			e.ft2Tick = x - 1
+		}
+	}
+	return e.ft2Update(keyOn)
+}
+
+// ft2Update runs the counter on by 1 tick and handles reaching a point, returning true if the
+// point reached was the last one that can be reached
+func (e *State[T]) ft2Update(keyOn bool) bool {
+	e.ft2Tick++
+	p := e.position
+	if e.ft2Tick != e.ft2PointTick(p) {
+		return false
+	}
+
+	e.ft2Base = p
+	e.ft2Interp = false
+	next := p + 1
+
+	sustain, sustainOK := ft2Point(e.env.Sustain)
+	if begin, end, ok := ft2Range(e.env.Loop); ok && p == end {
+		// a sustain point on the loop end keeps the loop going until key-off
+		if !sustainOK || p != sustain || keyOn {
+			e.ft2Base = begin
+			e.ft2Tick = e.ft2PointTick(begin)
+			next = begin + 1
+		}
+	}
+
+	if next >= len(e.env.Values) {
+		return true
+	}
+
+	if sustainOK && keyOn && next-1 == sustain {
+		// held on the sustain point; the counter runs on, but nothing else is reached until key-off
+		return false
+	}
+
+	e.position = next
+	e.ft2Interp = e.ft2PointTick(next) > e.ft2PointTick(next-1)
+	return false
+}
+
+// ft2Seek sets the position of the state the way FT2's envelope position effect (Lxx) does, then
+// runs the update for the tick. The position is found on the envelope's timeline without regard
+// to the loop or sustain, so a position past the loop end leaves the loop behind, and a position
+// past the last point stays on the last point.
+// FT2 only applies Lxx to the panning envelope when the volume envelope's sustain flag is set; as
+// each state only knows its own envelope, that is left to the player (see voice.SetAllEnvelopePositions).
+func (e *State[T]) ft2Seek(ticks int, keyOn bool) bool {
+	last := len(e.env.Values) - 1
+	e.ft2Tick = ticks - 1
+	e.position = last
+	e.ft2Base = last
+	e.ft2Interp = false
+	for i := 1; i <= last; i++ {
+		x := e.ft2PointTick(i)
+		if ticks >= x {
+			continue
+		}
+
+		p := i - 1
+		if ticks == e.ft2PointTick(p) {
+			// the point itself is reached by the update
+			e.position = p
+			if p > 0 {
+				e.ft2Base = p - 1
+				e.ft2Interp = true
+			} else {
+				e.ft2Base = 0
+			}
+		} else {
+			e.position = i
+			e.ft2Base = p
+			e.ft2Interp = true
+		}
+		break
+	}
+	return e.ft2Update(keyOn)
+}
+
+// ft2Edit fixes up the state after the points of the envelope were changed
+func (e *State[T]) ft2Edit(edit PointEdit) {
+	shift := func(v int) int {
+		switch {
+		case edit.Shift > 0 && v >= edit.Index:
+			return v + 1
+		case edit.Shift < 0 && v > edit.Index:
+			return v - 1
+		}
+		return v
+	}
+	e.position = shift(e.position)
+	e.ft2Base = shift(e.ft2Base)
+
+	last := len(e.env.Values) - 1
+	if e.position > last {
+		e.position = last
+		e.ft2Interp = false
+	}
+	if e.ft2Base > last {
+		e.ft2Base = last
+	}
+	if e.ft2Interp {
+		if e.position == 0 {
+			e.ft2Interp = false
+		} else {
+			e.ft2Base = e.position - 1
+			if x := e.ft2PointTick(e.position); e.ft2Tick >= x {
+				e.ft2Tick = x - 1
+			}
+		}
+	}
+}
+
+// ft2Value returns the points that the value lies between, and how far (0 through 1) it is between them
+func (e *State[T]) ft2Value() (*EnvPoint[T], *EnvPoint[T], float32) {
+	cur := e.env.Values[e.ft2Base]
+	if !e.ft2Interp {
+		return &cur, &cur, 0
+	}
+
+	next := e.env.Values[e.position]
+	x0 := e.ft2PointTick(e.ft2Base)
+	x1 := e.ft2PointTick(e.position)
+	t := float32(e.ft2Tick-x0) / float32(x1-x0)
+	switch {
+	case t < 0:
+		t = 0
+	case t > 1:
+		t = 1
+	}
+	return &cur, &next, t
+}
+
+// ft2Playhead returns the current playback position of the state
+func (e *State[T]) ft2Playhead(keyOn bool) Playhead {
+	_, _, t := e.ft2Value()
+	sustain, sustainOK := ft2Point(e.env.Sustain)
+	begin, end, loopOK := ft2Range(e.env.Loop)
+	return Playhead{
+		Tick:      e.ft2Tick,
+		Point:     e.ft2Base,
+		Fraction:  t,
+		InSustain: sustainOK && keyOn && !e.ft2Interp && e.ft2Base == sustain,
+		InLoop:    loopOK && e.ft2Base >= begin && e.ft2Base <= end,
+	}
+}
+
+// ft2PointTick returns the tick on the envelope's timeline of the point
+func (e *State[T]) ft2PointTick(index int) int {
+	tick, _ := e.env.PointTick(index)
+	return tick
+}
+
+// ft2Point returns the sustain point of the sustain loop, if it is enabled
+func ft2Point(l loop.Loop) (int, bool) {
+	sp, ok := l.(loop.SettingsProvider)
+	if !ok || !l.Enabled() {
+		return 0, false
+	}
+	return sp.GetSettings().Begin, true
+}
+
+// ft2Range returns the loop begin and end points of the loop, if it is enabled
+func ft2Range(l loop.Loop) (int, int, bool) {
+	sp, ok := l.(loop.SettingsProvider)
+	if !ok || !l.Enabled() {
+		return 0, 0, false
+	}
+	s := sp.GetSettings()
+	return s.Begin, s.End, true
+}
@@ -0,0 +1,139 @@
+package envelope
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gotracker/voice/loop"
+)
+
+// newFT2Envelope returns an FT2 envelope with the points (as tick and value pairs on the envelope's
+// timeline), a loop between the `loopBegin` and `loopEnd` points, and a sustain point (both of which
+// are left out when negative)
+func newFT2Envelope(points [][2]int, loopBegin, loopEnd, sustain int) *Envelope[int] {
+	env := &Envelope[int]{
+		Enabled: true,
+		FT2:     true,
+		Loop:    &loop.Disabled{},
+		Sustain: &loop.Disabled{},
+	}
+	if loopBegin >= 0 {
+		env.Loop = &loop.Normal{Settings: loop.Settings{Begin: loopBegin, End: loopEnd}}
+	}
+	if sustain >= 0 {
+		env.Sustain = &loop.Normal{Settings: loop.Settings{Begin: sustain, End: sustain + 1}}
+	}
+	for i, p := range points {
+		ticks := 1
+		if i+1 < len(points) {
+			ticks = points[i+1][0] - p[0]
+		}
+		env.Values = append(env.Values, EnvPoint[int]{
+			Ticks: ticks,
+			Y:     p[1],
+		})
+	}
+	return env
+}
+
+func ft2Value(s *State[int]) float32 {
+	cur, next, t := s.GetCurrentValue(true)
+	if cur == nil {
+		return -1
+	}
+	y0, y1 := float32(cur.Y), float32(next.Y)
+	return y0 + t*(y1-y0)
+}
+
+var ft2TestPoints = [][2]int{{0, 0}, {2, 20}, {4, 40}, {6, 60}, {8, 80}}
+
+// The expected values below come from FT2's envelope handling (as in ft2clone), with the key
+// released just before the update of the tick given by keyOff.
+func TestFT2Advance(t *testing.T) {
+	tests := []struct {
+		name               string
+		loopBegin, loopEnd int
+		sustain            int
+		keyOff             int
+		want               []float32
+	}{
+		{
+			name:      "sustain point",
+			loopBegin: -1, sustain: 1,
+			keyOff: 5,
+			want:   []float32{0, 10, 20, 20, 20, 20, 30, 40, 50, 60, 70, 80, 80},
+		},
+		{
+			name:      "loop",
+			loopBegin: 1, loopEnd: 3, sustain: -1,
+			want: []float32{0, 10, 20, 30, 40, 50, 20, 30, 40, 50, 20, 30},
+		},
+		{
+			name:      "sustain point on loop end",
+			loopBegin: 1, loopEnd: 3, sustain: 3,
+			keyOff: 10,
+			want:   []float32{0, 10, 20, 30, 40, 50, 20, 30, 40, 50, 60, 70, 80, 80, 80, 80, 80},
+		},
+		{
+			name:      "sustain point within loop",
+			loopBegin: 1, loopEnd: 3, sustain: 2,
+			keyOff: 8,
+			want:   []float32{0, 10, 20, 30, 40, 40, 40, 40, 40, 50, 20, 30, 40, 50, 20, 30, 40},
+		},
+		{
+			name:      "sustain point after loop end",
+			loopBegin: 1, loopEnd: 2, sustain: 3,
+			keyOff: 8,
+			want:   []float32{0, 10, 20, 30, 20, 30, 20, 30, 20, 30, 20, 30, 20, 30, 20},
+		},
+	}
+	for _, tc := range tests {
+		env := newFT2Envelope(ft2TestPoints, tc.loopBegin, tc.loopEnd, tc.sustain)
+		var s State[int]
+		s.Reset(env)
+		got := []float32{ft2Value(&s)}
+		for i := 1; i < len(tc.want); i++ {
+			keyOn := tc.keyOff == 0 || i < tc.keyOff
+			prevKeyOn := tc.keyOff == 0 || i-1 < tc.keyOff
+			s.Advance(keyOn, prevKeyOn)
+			got = append(got, ft2Value(&s))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// The expected values below come from FT2's envelope position effect (Lxx, as in ft2clone), with
+// the key held.
+func TestFT2Seek(t *testing.T) {
+	tests := []struct {
+		name               string
+		loopBegin, loopEnd int
+		sustain            int
+		ticks              int
+		want               []float32
+	}{
+		{"start", 1, 2, -1, 0, []float32{0, 10, 20, 30, 20, 30}},
+		{"on a point", 1, 2, -1, 2, []float32{20, 30, 20, 30, 20, 30}},
+		{"between points", 1, 2, -1, 3, []float32{30, 20, 30, 20, 30, 20}},
+		{"past the loop end", 1, 2, -1, 5, []float32{50, 60, 70, 80, 80, 80}},
+		{"past the last point", 1, 2, -1, 20, []float32{80, 80, 80, 80, 80, 80}},
+		{"before the sustain point", -1, 0, 1, 1, []float32{10, 20, 20, 20, 20, 20}},
+		{"past the sustain point", -1, 0, 1, 5, []float32{50, 60, 70, 80, 80, 80}},
+	}
+	for _, tc := range tests {
+		env := newFT2Envelope(ft2TestPoints, tc.loopBegin, tc.loopEnd, tc.sustain)
+		var s State[int]
+		s.Reset(env)
+		s.Seek(tc.ticks, true, true)
+		got := []float32{ft2Value(&s)}
+		for i := 1; i < len(tc.want); i++ {
+			s.Advance(true, true)
+			got = append(got, ft2Value(&s))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
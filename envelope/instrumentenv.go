@@ -1,23 +1,30 @@
 package envelope
 
 import (
+	"time"
+
 	"github.com/gotracker/voice"
 	"github.com/gotracker/voice/loop"
 )
 
 // Envelope is an envelope for instruments
 type Envelope[T any] struct {
-	Enabled    bool
-	Loop       loop.Loop
-	Sustain    loop.Loop
-	Values     []EnvPoint[T]
-	OnFinished voice.Callback
+	Enabled       bool
+	Loop          loop.Loop
+	Sustain       loop.Loop
+	Values        []EnvPoint[T]
+	Interpolation Interpolation
+	Carry         bool          // continue from the previous note's position instead of restarting (IT-style)
+	TimeBase      time.Duration // when non-zero, point lengths are in units of this duration instead of ticks
+	FT2           bool          // follow FastTracker 2's envelope behaviour (sustain point, loop quirks and Lxx)
+	OnFinished    voice.Callback
 }
 
 // EnvPoint is a point for the envelope
 type EnvPoint[T any] struct {
-	Ticks int
-	Y     T
+	Ticks         int           `json:"ticks" yaml:"ticks"`
+	Y             T             `json:"y" yaml:"y"`
+	Interpolation Interpolation `json:"interpolation,omitempty" yaml:"interpolation,omitempty"` // interpolation towards the next point, overriding the envelope's
 }
 
 func (p EnvPoint[T]) Length() int {
@@ -28,6 +35,17 @@ func (p EnvPoint[T]) Value() T {
 	return p.Y
 }
 
+// InterpolationFor returns the interpolation used from this point to the next within the envelope
+func (p EnvPoint[T]) InterpolationFor(env *Envelope[T]) Interpolation {
+	if p.Interpolation != InterpolationDefault {
+		return p.Interpolation
+	}
+	if env != nil {
+		return env.Interpolation
+	}
+	return InterpolationDefault
+}
+
 func (p *EnvPoint[T]) Init(ticks int, value T) {
 	p.Ticks = ticks
 	p.Y = value
@@ -0,0 +1,48 @@
+package envelope
+
+import "math"
+
+// Interpolation is the method used to move between the values of two envelope points
+type Interpolation uint8
+
+const (
+	// InterpolationDefault is for using the envelope's interpolation (on a point) or linear (on an envelope)
+	InterpolationDefault = Interpolation(iota)
+	// InterpolationLinear is for moving between points at a constant rate
+	InterpolationLinear
+	// InterpolationStep is for holding the value of a point until the next one is reached
+	InterpolationStep
+	// InterpolationExponential is for moving slowly away from a point, then quickly into the next one
+	InterpolationExponential
+	// InterpolationLogarithmic is for moving quickly away from a point, then slowly into the next one
+	InterpolationLogarithmic
+	// InterpolationCubic is for easing out of a point and into the next one (cubic hermite with flat tangents)
+	InterpolationCubic
+)
+
+// curveSteepness controls the bend of the exponential and logarithmic curves
+const curveSteepness = 4
+
+// Shape converts the linear fraction `t` (0 through 1) of the way between two points into the
+// fraction that the interpolation method has moved the value between them
+func (i Interpolation) Shape(t float32) float32 {
+	switch {
+	case t <= 0:
+		return 0
+	case t >= 1:
+		return 1
+	}
+
+	switch i {
+	case InterpolationStep:
+		return 0
+	case InterpolationExponential:
+		return float32(math.Expm1(curveSteepness*float64(t)) / math.Expm1(curveSteepness))
+	case InterpolationLogarithmic:
+		return 1 - float32(math.Expm1(curveSteepness*float64(1-t))/math.Expm1(curveSteepness))
+	case InterpolationCubic:
+		return t * t * (3 - 2*t)
+	default:
+		return t
+	}
+}
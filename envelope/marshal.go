@@ -0,0 +1,138 @@
+package envelope
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gotracker/voice/loop"
+)
+
+// ErrUnknownInterpolation is for when an interpolation name is not recognized
+var ErrUnknownInterpolation = errors.New("unknown envelope interpolation")
+
+var interpolationNames = []string{
+	InterpolationDefault:     "default",
+	InterpolationLinear:      "linear",
+	InterpolationStep:        "step",
+	InterpolationExponential: "exponential",
+	InterpolationLogarithmic: "logarithmic",
+	InterpolationCubic:       "cubic",
+}
+
+// MarshalText returns the name of the interpolation
+func (i Interpolation) MarshalText() ([]byte, error) {
+	if int(i) >= len(interpolationNames) {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownInterpolation, i)
+	}
+	return []byte(interpolationNames[i]), nil
+}
+
+// UnmarshalText sets the interpolation from its name
+func (i *Interpolation) UnmarshalText(text []byte) error {
+	for v, name := range interpolationNames {
+		if string(text) == name {
+			*i = Interpolation(v)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownInterpolation, text)
+}
+
+// envelopeText is the text (JSON/YAML) form of an envelope
+type envelopeText[T any] struct {
+	Enabled       bool            `json:"enabled" yaml:"enabled"`
+	Loop          loop.Definition `json:"loop" yaml:"loop"`
+	Sustain       loop.Definition `json:"sustain" yaml:"sustain"`
+	Points        []EnvPoint[T]   `json:"points" yaml:"points"`
+	Interpolation Interpolation   `json:"interpolation,omitempty" yaml:"interpolation,omitempty"`
+	Carry         bool            `json:"carry,omitempty" yaml:"carry,omitempty"`
+	TimeBase      string          `json:"timeBase,omitempty" yaml:"timeBase,omitempty"` // e.g. "10ms"
+	FT2           bool            `json:"ft2,omitempty" yaml:"ft2,omitempty"`
+}
+
+func (e *Envelope[T]) toText() (envelopeText[T], error) {
+	loopDef, err := loop.DefinitionOf(e.Loop)
+	if err != nil {
+		return envelopeText[T]{}, err
+	}
+	sustainDef, err := loop.DefinitionOf(e.Sustain)
+	if err != nil {
+		return envelopeText[T]{}, err
+	}
+
+	t := envelopeText[T]{
+		Enabled:       e.Enabled,
+		Loop:          loopDef,
+		Sustain:       sustainDef,
+		Points:        e.Values,
+		Interpolation: e.Interpolation,
+		Carry:         e.Carry,
+		FT2:           e.FT2,
+	}
+	if e.TimeBase != 0 {
+		t.TimeBase = e.TimeBase.String()
+	}
+	return t, nil
+}
+
+func (e *Envelope[T]) fromText(t envelopeText[T]) error {
+	l, err := t.Loop.Loop(len(t.Points))
+	if err != nil {
+		return err
+	}
+	sustain, err := t.Sustain.Loop(len(t.Points))
+	if err != nil {
+		return err
+	}
+
+	var timeBase time.Duration
+	if t.TimeBase != "" {
+		if timeBase, err = time.ParseDuration(t.TimeBase); err != nil {
+			return err
+		}
+	}
+
+	e.Enabled = t.Enabled
+	e.Loop = l
+	e.Sustain = sustain
+	e.Values = t.Points
+	e.Interpolation = t.Interpolation
+	e.Carry = t.Carry
+	e.TimeBase = timeBase
+	e.FT2 = t.FT2
+	return nil
+}
+
+// MarshalJSON returns the JSON form of the envelope. The OnFinished callback is not included.
+func (e Envelope[T]) MarshalJSON() ([]byte, error) {
+	t, err := e.toText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(t)
+}
+
+// UnmarshalJSON sets the envelope from its JSON form, leaving the OnFinished callback as it was
+func (e *Envelope[T]) UnmarshalJSON(data []byte) error {
+	var t envelopeText[T]
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	return e.fromText(t)
+}
+
+// MarshalYAML returns the YAML form of the envelope. The OnFinished callback is not included.
+func (e Envelope[T]) MarshalYAML() (interface{}, error) {
+	return e.toText()
+}
+
+// UnmarshalYAML sets the envelope from its YAML form, leaving the OnFinished callback as it was
+func (e *Envelope[T]) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var t envelopeText[T]
+	if err := unmarshal(&t); err != nil {
+		return err
+	}
+	return e.fromText(t)
+}
@@ -0,0 +1,152 @@
+package envelope
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotracker/gomixing/panning"
+	"github.com/gotracker/gomixing/volume"
+	"gopkg.in/yaml.v2"
+
+	"github.com/gotracker/voice/loop"
+)
+
+// testRoundTrip checks that the envelope comes back the same from its JSON and YAML forms, and
+// that the JSON form contains each of the fragments
+func testRoundTrip[T any](t *testing.T, name string, env Envelope[T], fragments ...string) {
+	t.Helper()
+	data, err := json.Marshal(env)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	for _, f := range fragments {
+		if !strings.Contains(string(data), f) {
+			t.Errorf("%s: JSON %s does not contain %s", name, data, f)
+		}
+	}
+
+	var got Envelope[T]
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	if !reflect.DeepEqual(got, env) {
+		t.Errorf("%s: got %#v, want %#v", name, got, env)
+	}
+
+	data, err = yaml.Marshal(env)
+	if err != nil {
+		t.Fatalf("%s: YAML: %v", name, err)
+	}
+	got = Envelope[T]{}
+	if err := yaml.Unmarshal(data, &got); err != nil {
+		t.Fatalf("%s: YAML: %v", name, err)
+	}
+	if !reflect.DeepEqual(got, env) {
+		t.Errorf("%s: YAML: got %#v, want %#v from\n%s", name, got, env, data)
+	}
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	testRoundTrip(t, "volume", Envelope[volume.Volume]{
+		Enabled: true,
+		Loop:    &loop.Normal{Settings: loop.Settings{Begin: 1, End: 3}},
+		Sustain: &loop.Normal{Settings: loop.Settings{Begin: 2, End: 3}},
+		Values: []EnvPoint[volume.Volume]{
+			{Ticks: 4, Y: 0},
+			{Ticks: 4, Y: 1, Interpolation: InterpolationExponential},
+			{Ticks: 2, Y: 0.5},
+			{Ticks: 1, Y: 0.25},
+		},
+		Interpolation: InterpolationCubic,
+		Carry:         true,
+		TimeBase:      10 * time.Millisecond,
+	}, `"interpolation":"exponential"`, `"interpolation":"cubic"`, `"timeBase":"10ms"`)
+
+	testRoundTrip(t, "pan", Envelope[panning.Position]{
+		Enabled: true,
+		Loop:    &loop.PingPong{Settings: loop.Settings{Begin: 0, End: 2}, Endpoints: loop.PingPongDuplicateNone},
+		Sustain: &loop.Disabled{},
+		Values: []EnvPoint[panning.Position]{
+			{Ticks: 3, Y: panning.Position{Angle: 0, Distance: 1}},
+			{Ticks: 3, Y: panning.Position{Angle: 1.5, Distance: 0.5}},
+		},
+	}, `"mode":"pingpong"`, `"endpoints":"none"`)
+
+	testRoundTrip(t, "int8", Envelope[int8]{
+		Enabled: true,
+		Loop:    &loop.Counted{Settings: loop.Settings{Begin: 1, End: 2}, Count: 4},
+		Sustain: &loop.Legacy{Settings: loop.Settings{Begin: 0, End: 1}},
+		Values: []EnvPoint[int8]{
+			{Ticks: 2, Y: -32},
+			{Ticks: 2, Y: 32, Interpolation: InterpolationStep},
+			{Ticks: 1, Y: 0},
+		},
+		FT2: true,
+	}, `"count":4`, `"ft2":true`)
+}
+
+func TestEnvelopeLoopDefinitions(t *testing.T) {
+	loops := []loop.Loop{
+		&loop.Disabled{},
+		&loop.Legacy{Settings: loop.Settings{Begin: 1, End: 3}},
+		&loop.Normal{Settings: loop.Settings{Begin: 1, End: 3}},
+		&loop.Counted{Settings: loop.Settings{Begin: 1, End: 3}, Count: 2},
+		&loop.Fractional{FractionalSettings: loop.FractionalSettings{Begin: 0.5, End: 2.5}},
+		&loop.Crossfade{Settings: loop.Settings{Begin: 1, End: 3}, Fade: 1, Curve: loop.CrossfadeCurveEqualPower},
+		&loop.PingPong{Settings: loop.Settings{Begin: 1, End: 3}, Endpoints: loop.PingPongDuplicateBoth},
+		&loop.PingPong{Settings: loop.Settings{Begin: 1, End: 3}, Endpoints: loop.PingPongDuplicateNone},
+		&loop.PingPong{Settings: loop.Settings{Begin: 1, End: 3}, Endpoints: loop.PingPongDuplicateBegin},
+		&loop.PingPong{Settings: loop.Settings{Begin: 1, End: 3}, Endpoints: loop.PingPongDuplicateEnd},
+	}
+	for _, l := range loops {
+		testRoundTrip(t, reflect.TypeOf(l).String(), Envelope[int8]{
+			Enabled: true,
+			Loop:    l,
+			Sustain: l,
+			Values: []EnvPoint[int8]{
+				{Ticks: 1, Y: 0},
+				{Ticks: 1, Y: 1},
+				{Ticks: 1, Y: 2},
+				{Ticks: 1, Y: 3},
+			},
+		})
+	}
+}
+
+func TestEnvelopeJSONErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		err  error
+	}{
+		{
+			"unknown interpolation",
+			`{"enabled":true,"loop":{"mode":"disabled"},"sustain":{"mode":"disabled"},"points":[{"ticks":1,"y":0}],"interpolation":"wiggly"}`,
+			ErrUnknownInterpolation,
+		},
+		{
+			"loop past the points",
+			`{"enabled":true,"loop":{"mode":"normal","begin":0,"end":3},"sustain":{"mode":"disabled"},"points":[{"ticks":1,"y":0}]}`,
+			loop.ErrPastLength,
+		},
+	}
+	for _, tc := range tests {
+		var env Envelope[int8]
+		if err := json.Unmarshal([]byte(tc.json), &env); !errors.Is(err, tc.err) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.err)
+		}
+	}
+
+	env := Envelope[int8]{
+		Loop:    &loop.Disabled{},
+		Sustain: &loop.Disabled{},
+	}
+	env.Values = []EnvPoint[int8]{{Ticks: 1, Interpolation: Interpolation(200)}}
+	if _, err := json.Marshal(env); !errors.Is(err, ErrUnknownInterpolation) {
+		t.Errorf("marshaling an unknown interpolation: got %v, want %v", err, ErrUnknownInterpolation)
+	}
+}
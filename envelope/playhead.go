@@ -0,0 +1,71 @@
+package envelope
+
+import "github.com/gotracker/voice/loop"
+
+// Playhead is the playback position of an envelope state
+type Playhead struct {
+	Tick      int     // ticks from the start of the envelope to the current position
+	Point     int     // index of the point at the start of the current segment
+	Fraction  float32 // how far (0 through 1) the position is through the current segment
+	InSustain bool    // true if the position is within the sustain, while it is in effect
+	InLoop    bool    // true if the position is within the loop, while it is in effect
+	Stopped   bool    // true if the envelope has stopped
+}
+
+// Playhead returns the current playback position of the state
+func (e *State[T]) Playhead(keyOn bool) Playhead {
+	if e.env == nil || !e.env.Enabled || len(e.env.Values) == 0 {
+		return Playhead{
+			Stopped: true,
+		}
+	}
+
+	if e.env.FT2 && !e.stopped {
+		return e.ft2Playhead(keyOn)
+	}
+
+	pos, _, looped := e.calcLoopedPos(keyOn)
+	if e.stopped || pos >= len(e.env.Values) {
+		last := len(e.env.Values) - 1
+		tick, _ := e.env.PointTick(last)
+		return Playhead{
+			Tick:    tick,
+			Point:   last,
+			Stopped: true,
+		}
+	}
+
+	cur := e.env.Values[pos]
+	t := e.segmentFraction(cur, keyOn, looped)
+	tick, _ := e.env.PointTick(pos)
+	into := cur.Length() - e.length
+	if t >= 1 || into > cur.Length() {
+		into = cur.Length()
+	}
+	if into > 0 {
+		tick += into
+	}
+
+	sustaining := keyOn && e.env.Sustain.Enabled()
+	return Playhead{
+		Tick:      tick,
+		Point:     pos,
+		Fraction:  t,
+		InSustain: sustaining && pointInLoop(e.env.Sustain, pos),
+		InLoop:    !sustaining && pointInLoop(e.env.Loop, pos),
+	}
+}
+
+// pointInLoop returns true if the point is covered by the loop
+func pointInLoop(l loop.Loop, point int) bool {
+	sp, ok := l.(loop.SettingsProvider)
+	if !ok || !l.Enabled() {
+		return false
+	}
+
+	s := sp.GetSettings()
+	if s.Begin == s.End {
+		return point == s.Begin
+	}
+	return point >= s.Begin && point < s.End
+}
@@ -0,0 +1,133 @@
+package envelope
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gotracker/voice/loop"
+)
+
+var (
+	// ErrEmptyEnvelope is for when an enabled envelope has no points
+	ErrEmptyEnvelope = errors.New("envelope has no points")
+	// ErrMissingLoop is for when an envelope's loop or sustain is not set
+	ErrMissingLoop = errors.New("envelope loop or sustain is not set")
+	// ErrNonPositiveLength is for when a point (other than the last) has a length of zero or less
+	ErrNonPositiveLength = errors.New("envelope point length is not positive")
+	// ErrLoopOutOfRange is for when an envelope's loop does not fit within its points
+	ErrLoopOutOfRange = errors.New("envelope loop is outside of the points")
+	// ErrSustainOutOfRange is for when an envelope's sustain does not fit within its points
+	ErrSustainOutOfRange = errors.New("envelope sustain is outside of the points")
+	// ErrSustainAfterLoop is for when an envelope's sustain begins after the end of its loop (FT2
+	// envelopes allow this, as FT2 checks the sustain point and the loop separately)
+	ErrSustainAfterLoop = errors.New("envelope sustain is after the loop end")
+)
+
+// ValidationError is a problem found when validating an envelope
+type ValidationError struct {
+	Err    error // one of the Err* values
+	Point  int   // index of the point involved, or -1 if not specific to a point
+	Detail string
+}
+
+// Error returns the description of the problem
+func (v ValidationError) Error() string {
+	msg := v.Err.Error()
+	if v.Point >= 0 {
+		msg = fmt.Sprintf("%s (point %d)", msg, v.Point)
+	}
+	if v.Detail != "" {
+		msg = fmt.Sprintf("%s: %s", msg, v.Detail)
+	}
+	return msg
+}
+
+// Unwrap returns the underlying Err* value
+func (v ValidationError) Unwrap() error {
+	return v.Err
+}
+
+// ValidationErrors is the set of problems found when validating an envelope
+type ValidationErrors []ValidationError
+
+// Error returns the description of all of the problems
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, len(v))
+	for i, err := range v {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is returns true if any of the problems match the target error
+func (v ValidationErrors) Is(target error) bool {
+	for _, err := range v {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the envelope for problems that would cause it to misbehave during playback.
+// It returns nil if there are none, otherwise it returns a ValidationErrors listing all of them.
+// Disabled envelopes are not checked, as they are never played.
+func (e *Envelope[T]) Validate() error {
+	if !e.Enabled {
+		return nil
+	}
+
+	var errs ValidationErrors
+	add := func(err error, point int, detail string) {
+		errs = append(errs, ValidationError{
+			Err:    err,
+			Point:  point,
+			Detail: detail,
+		})
+	}
+
+	nPoints := len(e.Values)
+	if nPoints == 0 {
+		add(ErrEmptyEnvelope, -1, "")
+	}
+
+	// the last point is allowed to have no length, as its length only sets how long its value is
+	// held before the envelope finishes
+	for i := 0; i < nPoints-1; i++ {
+		if ticks := e.Values[i].Length(); ticks <= 0 {
+			add(ErrNonPositiveLength, i, fmt.Sprintf("length %d", ticks))
+		}
+	}
+
+	checkLoop := func(name string, l loop.Loop, outOfRange error) (loop.Settings, bool) {
+		if l == nil {
+			add(ErrMissingLoop, -1, name)
+			return loop.Settings{}, false
+		}
+		if !l.Enabled() {
+			return loop.Settings{}, false
+		}
+		sp, ok := l.(loop.SettingsProvider)
+		if !ok {
+			return loop.Settings{}, false
+		}
+		s := sp.GetSettings()
+		if err := s.Validate(nPoints); err != nil {
+			add(outOfRange, -1, err.Error())
+			return s, false
+		}
+		return s, true
+	}
+
+	loopSettings, loopOK := checkLoop("loop", e.Loop, ErrLoopOutOfRange)
+	sustainSettings, sustainOK := checkLoop("sustain", e.Sustain, ErrSustainOutOfRange)
+	if !e.FT2 && loopOK && sustainOK && sustainSettings.Begin > loopSettings.End {
+		add(ErrSustainAfterLoop, sustainSettings.Begin, fmt.Sprintf("loop end %d", loopSettings.End))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
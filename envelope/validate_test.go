@@ -0,0 +1,130 @@
+package envelope
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gotracker/voice/loop"
+)
+
+func TestValidate(t *testing.T) {
+	normal := func(begin, end int) loop.Loop {
+		return &loop.Normal{Settings: loop.Settings{Begin: begin, End: end}}
+	}
+	tests := []struct {
+		name    string
+		env     *Envelope[int]
+		wantErr []error
+	}{
+		{
+			name: "valid",
+			env:  newEditEnvelope(normal(1, 3), 2, 2, 2, 0),
+		},
+		{
+			name: "disabled is not checked",
+			env:  &Envelope[int]{},
+		},
+		{
+			name:    "no points",
+			env:     newEditEnvelope(&loop.Disabled{}),
+			wantErr: []error{ErrEmptyEnvelope},
+		},
+		{
+			name:    "missing loop",
+			env:     newEditEnvelope(nil, 2, 2),
+			wantErr: []error{ErrMissingLoop},
+		},
+		{
+			name: "missing sustain",
+			env: func() *Envelope[int] {
+				env := newEditEnvelope(&loop.Disabled{}, 2, 2)
+				env.Sustain = nil
+				return env
+			}(),
+			wantErr: []error{ErrMissingLoop},
+		},
+		{
+			name:    "point without length",
+			env:     newEditEnvelope(&loop.Disabled{}, 2, 0, 2),
+			wantErr: []error{ErrNonPositiveLength},
+		},
+		{
+			name:    "loop past the points",
+			env:     newEditEnvelope(normal(1, 5), 2, 2, 2),
+			wantErr: []error{ErrLoopOutOfRange},
+		},
+		{
+			name: "sustain past the points",
+			env: func() *Envelope[int] {
+				env := newEditEnvelope(&loop.Disabled{}, 2, 2, 2)
+				env.Sustain = normal(2, 4)
+				return env
+			}(),
+			wantErr: []error{ErrSustainOutOfRange},
+		},
+		{
+			name: "sustain after loop end",
+			env: func() *Envelope[int] {
+				env := newEditEnvelope(normal(0, 1), 2, 2, 2, 2)
+				env.Sustain = normal(2, 3)
+				return env
+			}(),
+			wantErr: []error{ErrSustainAfterLoop},
+		},
+		{
+			name: "FT2 sustain after loop end",
+			env:  newFT2Envelope(ft2TestPoints, 1, 2, 3),
+		},
+		{
+			name: "several problems",
+			env: func() *Envelope[int] {
+				env := newEditEnvelope(normal(1, 9), 2, -1, 2)
+				env.Sustain = normal(3, 4)
+				return env
+			}(),
+			wantErr: []error{ErrNonPositiveLength, ErrLoopOutOfRange, ErrSustainOutOfRange},
+		},
+	}
+	for _, tc := range tests {
+		err := tc.env.Validate()
+		if len(tc.wantErr) == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tc.name, err)
+			}
+			continue
+		}
+
+		var verrs ValidationErrors
+		if !errors.As(err, &verrs) {
+			t.Errorf("%s: got %v, want ValidationErrors", tc.name, err)
+			continue
+		}
+		if len(verrs) != len(tc.wantErr) {
+			t.Errorf("%s: got %d errors (%v), want %d", tc.name, len(verrs), err, len(tc.wantErr))
+		}
+		for _, want := range tc.wantErr {
+			if !verrs.Is(want) || !errors.Is(err, want) {
+				t.Errorf("%s: got %v, want %v", tc.name, err, want)
+			}
+		}
+		if verrs.Is(errNotAValidationError) {
+			t.Errorf("%s: Is matched an unrelated error", tc.name)
+		}
+	}
+}
+
+var errNotAValidationError = errors.New("not a validation error")
+
+func TestValidationErrorPoint(t *testing.T) {
+	env := newEditEnvelope(&loop.Disabled{}, 2, 2, 0, 2)
+	var verrs ValidationErrors
+	if !errors.As(env.Validate(), &verrs) || len(verrs) != 1 {
+		t.Fatalf("got %v, want 1 error", verrs)
+	}
+	if verrs[0].Point != 2 {
+		t.Errorf("got point %d, want 2", verrs[0].Point)
+	}
+	if want := "envelope point length is not positive (point 2): length 0"; verrs.Error() != want {
+		t.Errorf("got %q, want %q", verrs.Error(), want)
+	}
+}
@@ -0,0 +1,6 @@
+package voice
+
+// EnvelopeCarrier is an envelope carry interface
+type EnvelopeCarrier interface {
+	CarryEnvelopes(prev Voice)
+}
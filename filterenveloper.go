@@ -7,3 +7,9 @@ type FilterEnveloper interface {
 	GetCurrentFilterEnvelope() int8
 	SetFilterEnvelopePosition(pos int)
 }
+
+// FilterEnvelopeRamper is an interface for voices that can provide the filter envelope's value partway
+// through a tick, ramping from the previous tick's value to the current one
+type FilterEnvelopeRamper interface {
+	GetFilterEnvelopeAt(t float32) int8
+}
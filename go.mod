@@ -5,4 +5,5 @@ go 1.18
 require (
 	github.com/gotracker/gomixing v1.1.2
 	github.com/gotracker/opl2 v1.0.1
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -12,6 +12,9 @@ const (
 	pi8 = math.Pi / 8
 
 	twopi = math.Pi * 2
+
+	// oppositeEpsilon is how close (in radians) two angles need to be to opposite to be treated as opposite
+	oppositeEpsilon = 1e-5
 )
 
 // CalculateCombinedPanning calculates a panning value where `p1` modifies
@@ -53,3 +56,33 @@ func GetPanningDifference(p0, p1 panning.Position) panning.Position {
 		Distance: float32(fd),
 	}
 }
+
+// Lerp interpolates between `p0` and `p1` by `t` (0 through 1), moving the angle along the
+// shortest arc between them (across the wrap point, if needed) so that the position moves
+// around the listener at a constant rate and power, rather than swinging through the far side
+func Lerp(p0, p1 panning.Position, t float32) panning.Position {
+	a0 := float64(p0.Angle)
+	a1 := float64(p1.Angle)
+
+	// shortest signed angular distance, in the range [-pi, pi)
+	da := math.Mod(a1-a0+math.Pi, twopi)
+	if da < 0 {
+		da += twopi
+	}
+	da -= math.Pi
+	if da > math.Pi-oppositeEpsilon {
+		// exactly opposite angles have no shortest arc, so always go the negative way around
+		// (rounding of the angles would otherwise pick a direction)
+		da -= twopi
+	}
+
+	fa := math.Mod(a0+float64(t)*da, twopi)
+	if fa < 0 {
+		fa += twopi
+	}
+
+	return panning.Position{
+		Angle:    float32(fa),
+		Distance: p0.Distance + t*(p1.Distance-p0.Distance),
+	}
+}
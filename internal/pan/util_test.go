@@ -0,0 +1,66 @@
+package pan
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gotracker/gomixing/panning"
+)
+
+func deg(d float64) float32 {
+	return float32(d * math.Pi / 180)
+}
+
+// angleDiff returns the distance (in degrees) between two angles, accounting for the wrap
+func angleDiff(a, b float32) float64 {
+	d := math.Mod(float64(a-b)*180/math.Pi, 360)
+	if d < 0 {
+		d += 360
+	}
+	return math.Min(d, 360-d)
+}
+
+func TestLerp(t *testing.T) {
+	tests := []struct {
+		name   string
+		p0, p1 float64 // degrees
+		t      float32
+		want   float64 // degrees
+	}{
+		{"350 to 10, start", 350, 10, 0, 350},
+		{"350 to 10, quarter", 350, 10, 0.25, 355},
+		{"350 to 10, middle", 350, 10, 0.5, 0},
+		{"350 to 10, end", 350, 10, 1, 10},
+		{"10 to 350, quarter", 10, 350, 0.25, 5},
+		{"10 to 350, middle", 10, 350, 0.5, 0},
+		{"10 to 350, three quarters", 10, 350, 0.75, 355},
+		{"10 to 350, end", 10, 350, 1, 350},
+		{"no wrap", 30, 60, 0.5, 45},
+		// exactly opposite angles have no shortest arc; the move goes the negative way around
+		{"opposite, middle", 10, 190, 0.5, 280},
+		{"opposite, end", 10, 190, 1, 190},
+		{"opposite reversed, middle", 190, 10, 0.5, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p0 := panning.Position{Angle: deg(tt.p0), Distance: 1}
+			p1 := panning.Position{Angle: deg(tt.p1), Distance: 1}
+			got := Lerp(p0, p1, tt.t)
+			if d := angleDiff(got.Angle, deg(tt.want)); d > 1e-3 {
+				t.Errorf("got %v degrees, want %v", float64(got.Angle)*180/math.Pi, tt.want)
+			}
+			if got.Angle < 0 || float64(got.Angle) >= twopi {
+				t.Errorf("angle %v is outside of [0, 2pi)", got.Angle)
+			}
+		})
+	}
+}
+
+func TestLerpDistance(t *testing.T) {
+	p0 := panning.Position{Angle: deg(350), Distance: 0.5}
+	p1 := panning.Position{Angle: deg(10), Distance: 1.5}
+	if got := Lerp(p0, p1, 0.25).Distance; math.Abs(float64(got)-0.75) > 1e-6 {
+		t.Errorf("got distance %v, want 0.75", got)
+	}
+}
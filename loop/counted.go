@@ -0,0 +1,64 @@
+package loop
+
+// Counted is a normal loop that only plays a limited number of times before continuing to the end
+//  |start>-----------------------loopEnd|------------| <= on playthrough 1, whole sample plays
+//  |-------------|loopBegin>-----loopEnd|------------| <= on playthrough 2 through Count+1, only the part that loops plays
+//  |-------------------------------------loopEnd>-end| <= after Count loops, playback continues to end
+type Counted struct {
+	Settings
+	Count int // number of times the loop repeats before continuing on to the end
+}
+
+// Enabled returns true if the loop is enabled
+func (l *Counted) Enabled() bool {
+	return true
+}
+
+// Length returns the length of the loop
+func (l *Counted) Length() int {
+	return calcLoopLen(l.Begin, l.End)
+}
+
+// CalcPos calculates the position based on the loop details
+func (l *Counted) CalcPos(pos int, length int) (int, bool) {
+	if pos < 0 {
+		return 0, false
+	}
+	if pos < l.End {
+		return pos, false
+	}
+
+	loopLen := l.Length()
+	if loopLen <= 0 || l.Count <= 0 {
+		if pos < length {
+			return pos, false
+		}
+		return length, false
+	}
+
+	dist := pos - l.End
+	if times := dist / loopLen; times < l.Count {
+		return l.Begin + dist%loopLen, true
+	}
+
+	// the loop has exited, so continue on from the loop end
+	pos = l.End + dist - l.Count*loopLen
+	if pos < length {
+		return pos, false
+	}
+	return length, false
+}
+
+// CalcIteration returns the number of completed loop iterations at the (unlooped) position
+func (l *Counted) CalcIteration(pos int) int {
+	loopLen := l.Length()
+	if pos < l.End || loopLen <= 0 {
+		return 0
+	}
+
+	times := (pos-l.End)/loopLen + 1
+	if times > l.Count {
+		times = l.Count
+	}
+	return times
+}
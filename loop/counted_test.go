@@ -0,0 +1,74 @@
+package loop
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCountedCalcPos(t *testing.T) {
+	const length = 10
+	tests := []struct {
+		name       string
+		loop       Counted
+		wantPos    []int
+		wantLooped []bool
+		wantIter   []int
+	}{
+		{
+			// 2 passes through 2..4, then on from the loop end to the end of the sample
+			name:       "count 2",
+			loop:       Counted{Settings: Settings{Begin: 2, End: 5}, Count: 2},
+			wantPos:    []int{0, 1, 2, 3, 4, 2, 3, 4, 2, 3, 4, 5, 6, 7, 8, 9, 10, 10},
+			wantLooped: []bool{false, false, false, false, false, true, true, true, true, true, true, false, false, false, false, false, false, false},
+			wantIter:   []int{0, 0, 0, 0, 0, 1, 1, 1, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2},
+		},
+		{
+			name:       "count 0",
+			loop:       Counted{Settings: Settings{Begin: 2, End: 5}, Count: 0},
+			wantPos:    []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 10},
+			wantLooped: []bool{false, false, false, false, false, false, false, false, false, false, false, false},
+			wantIter:   []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			// the last pass plays the whole loop before falling through
+			name:       "count 1 at the end of the sample",
+			loop:       Counted{Settings: Settings{Begin: 7, End: 10}, Count: 1},
+			wantPos:    []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 7, 8, 9, 10, 10},
+			wantLooped: []bool{false, false, false, false, false, false, false, false, false, false, true, true, true, false, false},
+			wantIter:   []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1},
+		},
+		{
+			name:       "empty loop",
+			loop:       Counted{Settings: Settings{Begin: 5, End: 5}, Count: 3},
+			wantPos:    []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+			wantLooped: []bool{false, false, false, false, false, false, false, false, false, false, false},
+			wantIter:   []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPos, gotIter []int
+			var gotLooped []bool
+			for pos := range tt.wantPos {
+				p, looped := tt.loop.CalcPos(pos, length)
+				gotPos = append(gotPos, p)
+				gotLooped = append(gotLooped, looped)
+				gotIter = append(gotIter, tt.loop.CalcIteration(pos))
+			}
+			if !reflect.DeepEqual(gotPos, tt.wantPos) {
+				t.Errorf("CalcPos got %v, want %v", gotPos, tt.wantPos)
+			}
+			if !reflect.DeepEqual(gotLooped, tt.wantLooped) {
+				t.Errorf("CalcPos looped got %v, want %v", gotLooped, tt.wantLooped)
+			}
+			if !reflect.DeepEqual(gotIter, tt.wantIter) {
+				t.Errorf("CalcIteration got %v, want %v", gotIter, tt.wantIter)
+			}
+		})
+	}
+
+	if p, looped := (&Counted{Settings: Settings{Begin: 2, End: 5}, Count: 2}).CalcPos(-1, length); p != 0 || looped {
+		t.Errorf("negative position: got %d, %v, want 0, false", p, looped)
+	}
+}
@@ -0,0 +1,91 @@
+package loop
+
+import "math"
+
+// CrossfadeCurve is the shape of the blend used by a crossfade loop
+type CrossfadeCurve uint8
+
+const (
+	// CrossfadeCurveLinear is for a linear (equal gain) crossfade, best for correlated material
+	CrossfadeCurveLinear = CrossfadeCurve(iota)
+	// CrossfadeCurveEqualPower is for a sine/cosine (equal power) crossfade, best for uncorrelated material
+	CrossfadeCurveEqualPower
+)
+
+// DefaultCrossfadeLength is the crossfade length (in samples) used by NewLoop for ModeCrossfade
+const DefaultCrossfadeLength = 256
+
+// Crossfade is a normal loop where the samples leading up to loopEnd are blended with the ones
+// leading up to loopBegin, so that the jump back to loopBegin is seamless
+//  |start>--------------<xfade>--loopEnd|------------| <= on playthrough 1, whole sample plays, already blended if looped (as with baked data)
+//  |-------------|loopBegin>---<xfade>--|------------| <= only if looped and on playthrough 2+, only the part that loops plays
+//  |-------------|loopBegin>----------------------end| <= on playthrough 2+, the loop ends and playback continues to end, if !keyOn
+type Crossfade struct {
+	Settings
+	Fade  int // length of the crossfade (in samples)
+	Curve CrossfadeCurve
+}
+
+// Enabled returns true if the loop is enabled
+func (l *Crossfade) Enabled() bool {
+	return true
+}
+
+// Length returns the length of the loop
+func (l *Crossfade) Length() int {
+	return calcLoopLen(l.Begin, l.End)
+}
+
+// CalcPos calculates the position based on the loop details
+func (l *Crossfade) CalcPos(pos int, length int) (int, bool) {
+	n := Normal{
+		Settings: l.Settings,
+	}
+	return n.CalcPos(pos, length)
+}
+
+// CalcIteration returns the number of completed loop iterations at the (unlooped) position
+func (l *Crossfade) CalcIteration(pos int) int {
+	n := Normal{
+		Settings: l.Settings,
+	}
+	return n.CalcIteration(pos)
+}
+
+// CrossfadeLength returns the effective length of the crossfade, which is limited by both the
+// length of the loop and the amount of data available before loopBegin
+func (l *Crossfade) CrossfadeLength() int {
+	xl := l.Fade
+	if loopLen := l.Length(); xl > loopLen {
+		xl = loopLen
+	}
+	if xl > l.Begin {
+		xl = l.Begin
+	}
+	if xl < 0 {
+		xl = 0
+	}
+	return xl
+}
+
+// CalcCrossfade returns details on how the sample at (already looped) position `pos` is blended:
+// the position of the other sample to blend with, the gain of the sample at `pos` and the gain of
+// the other sample. The final flag is false when `pos` is outside of the crossfade region.
+func (l *Crossfade) CalcCrossfade(pos int) (int, float32, float32, bool) {
+	xl := l.CrossfadeLength()
+	if xl <= 0 || pos < l.End-xl || pos >= l.End {
+		return pos, 1, 0, false
+	}
+
+	// t runs from just above 0 at the start of the region to 1 at the last sample before loopEnd,
+	// so that the final sample played is the one that immediately precedes loopBegin
+	t := float64(pos-(l.End-xl)+1) / float64(xl)
+	other := pos - l.Length()
+
+	switch l.Curve {
+	case CrossfadeCurveEqualPower:
+		return other, float32(math.Cos(t * math.Pi / 2)), float32(math.Sin(t * math.Pi / 2)), true
+	default:
+		return other, float32(1 - t), float32(t), true
+	}
+}
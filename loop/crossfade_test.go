@@ -0,0 +1,70 @@
+package loop
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCrossfadeLength(t *testing.T) {
+	tests := []struct {
+		name string
+		loop Crossfade
+		want int
+	}{
+		{"fits", Crossfade{Settings: Settings{Begin: 8, End: 16}, Fade: 4}, 4},
+		{"longer than the loop", Crossfade{Settings: Settings{Begin: 8, End: 12}, Fade: 6}, 4},
+		{"longer than the data before the loop", Crossfade{Settings: Settings{Begin: 3, End: 16}, Fade: 6}, 3},
+		{"loop at the start", Crossfade{Settings: Settings{Begin: 0, End: 16}, Fade: 6}, 0},
+		{"no fade", Crossfade{Settings: Settings{Begin: 8, End: 16}}, 0},
+		{"negative fade", Crossfade{Settings: Settings{Begin: 8, End: 16}, Fade: -2}, 0},
+	}
+	for _, tc := range tests {
+		if got := tc.loop.CrossfadeLength(); got != tc.want {
+			t.Errorf("%s: got %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCalcCrossfade(t *testing.T) {
+	type blend struct {
+		other           int
+		gain, otherGain float32
+		ok              bool
+	}
+	l := Crossfade{Settings: Settings{Begin: 6, End: 10}, Fade: 4}
+	want := map[int]blend{
+		5:  {5, 1, 0, false},
+		6:  {2, 0.75, 0.25, true},
+		7:  {3, 0.5, 0.5, true},
+		8:  {4, 0.25, 0.75, true},
+		9:  {5, 0, 1, true}, // the last sample before loopEnd is the one just before loopBegin
+		10: {10, 1, 0, false},
+	}
+	for pos, w := range want {
+		other, gain, otherGain, ok := l.CalcCrossfade(pos)
+		if got := (blend{other, gain, otherGain, ok}); got != w {
+			t.Errorf("pos %d: got %+v, want %+v", pos, got, w)
+		}
+	}
+}
+
+func TestCalcCrossfadeEqualPower(t *testing.T) {
+	l := Crossfade{Settings: Settings{Begin: 8, End: 16}, Fade: 8, Curve: CrossfadeCurveEqualPower}
+	for pos := l.End - l.Fade; pos < l.End; pos++ {
+		_, gain, otherGain, ok := l.CalcCrossfade(pos)
+		if !ok {
+			t.Fatalf("pos %d: not blended", pos)
+		}
+		// the power of the two parts always adds up to 1
+		if p := float64(gain*gain + otherGain*otherGain); math.Abs(p-1) > 1e-6 {
+			t.Errorf("pos %d: gains %v and %v have power %v, want 1", pos, gain, otherGain, p)
+		}
+		x := float64(pos-(l.End-l.Fade)+1) / float64(l.Fade) * math.Pi / 2
+		if math.Abs(float64(gain)-math.Cos(x)) > 1e-6 || math.Abs(float64(otherGain)-math.Sin(x)) > 1e-6 {
+			t.Errorf("pos %d: got gains %v and %v, want %v and %v", pos, gain, otherGain, math.Cos(x), math.Sin(x))
+		}
+	}
+	if _, gain, otherGain, _ := l.CalcCrossfade(l.End - 1); gain > 1e-6 || otherGain != 1 {
+		t.Errorf("last sample: got gains %v and %v, want 0 and 1", gain, otherGain)
+	}
+}
@@ -0,0 +1,72 @@
+package loop
+
+import "math"
+
+// FractionalSettings is details about a loop with sub-sample begin and end points
+type FractionalSettings struct {
+	Begin float64 `json:"begin" yaml:"begin"`
+	End   float64 `json:"end" yaml:"end"`
+}
+
+// Fractional is a normal loop with sub-sample loop points, so that the period of the loop is exact
+//  |start>-----------------------loopEnd|------------| <= on playthrough 1, whole sample plays
+//  |-------------|loopBegin>-----loopEnd|------------| <= only if looped and on playthrough 2+, only the part that loops plays
+//  |-------------|loopBegin>----------------------end| <= on playthrough 2+, the loop ends and playback continues to end, if !keyOn
+type Fractional struct {
+	FractionalSettings
+}
+
+// Enabled returns true if the loop is enabled
+func (l *Fractional) Enabled() bool {
+	return true
+}
+
+// Length returns the length of the loop, rounded to the nearest whole sample
+func (l *Fractional) Length() int {
+	return int(math.Round(l.LengthFrac()))
+}
+
+// LengthFrac returns the exact length of the loop
+func (l *Fractional) LengthFrac() float64 {
+	return l.End - l.Begin
+}
+
+// CalcPos calculates the position based on the loop details
+// The position returned is the whole sample containing the exact looped position
+func (l *Fractional) CalcPos(pos int, length int) (int, bool) {
+	p, looped := l.CalcPosFrac(float64(pos), length)
+	return int(math.Floor(p)), looped
+}
+
+// CalcPosFrac calculates the exact position based on the loop details
+func (l *Fractional) CalcPosFrac(pos float64, length int) (float64, bool) {
+	if pos < 0 {
+		return 0, false
+	}
+	if pos < l.End {
+		return pos, false
+	}
+
+	loopLen := l.LengthFrac()
+	if loopLen < 0 {
+		if pos < float64(length) {
+			return pos, false
+		}
+		return float64(length), false
+	} else if loopLen == 0 {
+		return l.Begin, true
+	}
+
+	dist := pos - l.End
+	loopedPos := math.Mod(dist, loopLen)
+	return l.Begin + loopedPos, true
+}
+
+// CalcIteration returns the number of completed loop iterations at the (unlooped) position
+func (l *Fractional) CalcIteration(pos int) int {
+	loopLen := l.LengthFrac()
+	if float64(pos) < l.End || loopLen <= 0 {
+		return 0
+	}
+	return int((float64(pos)-l.End)/loopLen) + 1
+}
@@ -0,0 +1,79 @@
+package loop
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestFractionalCalcIteration(t *testing.T) {
+	l := &Fractional{FractionalSettings: FractionalSettings{Begin: 2, End: 4.5}}
+	// passes of 2.5 samples start at 4.5, 7, 9.5 and 12
+	want := []int{0, 0, 0, 0, 0, 1, 1, 2, 2, 2, 3, 3, 4, 4}
+	var got []int
+	for pos := range want {
+		got = append(got, l.CalcIteration(pos))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	var _ IterationCounter = l
+	if _, ok := Loop(&Legacy{}).(IterationCounter); ok {
+		t.Error("Legacy should not be an IterationCounter")
+	}
+}
+
+func TestFractionalCalcPosFrac(t *testing.T) {
+	const length = 10
+	l := &Fractional{FractionalSettings: FractionalSettings{Begin: 2, End: 4.5}}
+	tests := []struct {
+		pos        float64
+		wantPos    float64
+		wantLooped bool
+	}{
+		{3, 3, false},
+		{4.25, 4.25, false},
+		{4.5, 2, true}, // first wrap
+		{5.75, 3.25, true},
+		{7, 2, true}, // second wrap
+		{9.4, 4.4, true},
+		{12, 2, true}, // fourth wrap
+		{14.6, 2.1, true},
+		{100, 2.5, true},
+	}
+	for _, tc := range tests {
+		got, looped := l.CalcPosFrac(tc.pos, length)
+		if math.Abs(got-tc.wantPos) > 1e-9 || looped != tc.wantLooped {
+			t.Errorf("%v: got %v, %v, want %v, %v", tc.pos, got, looped, tc.wantPos, tc.wantLooped)
+		}
+	}
+
+	// the whole position is the sample containing the exact one
+	wantWhole := []int{0, 1, 2, 3, 4, 2, 3, 2, 3, 4, 2, 3, 2}
+	var gotWhole []int
+	for pos := range wantWhole {
+		p, _ := l.CalcPos(pos, length)
+		gotWhole = append(gotWhole, p)
+	}
+	if !reflect.DeepEqual(gotWhole, wantWhole) {
+		t.Errorf("CalcPos got %v, want %v", gotWhole, wantWhole)
+	}
+
+	// the period stays exact however many times the loop wraps
+	for _, n := range []int{1, 10, 1000, 100000} {
+		pos := l.End + float64(n)*l.LengthFrac() + 0.3
+		if got, _ := l.CalcPosFrac(pos, length); math.Abs(got-2.3) > 1e-6 {
+			t.Errorf("%d wraps: got %v, want 2.3", n, got)
+		}
+	}
+
+	// a loop that ends before it begins does not loop
+	backwards := &Fractional{FractionalSettings: FractionalSettings{Begin: 4.5, End: 2}}
+	if got, looped := backwards.CalcPosFrac(6.5, length); got != 6.5 || looped {
+		t.Errorf("backwards: got %v, %v, want 6.5, false", got, looped)
+	}
+	if got, looped := backwards.CalcPosFrac(12, length); got != length || looped {
+		t.Errorf("backwards past the end: got %v, %v, want %d, false", got, looped, length)
+	}
+}
@@ -4,6 +4,8 @@ package loop
 //  |start>----------------------------------------end| <= on playthrough 1, whole sample plays
 //  |-------------|loopBegin>-----loopEnd|------------| <= only if looped and on playthrough 2+, only the part that loops plays
 //  |-------------|loopBegin>----------------------end| <= on playthrough 2+, the loop ends and playback continues to end, if !keyOn
+// It is not an IterationCounter, as the first iteration starts at the end of the sample, which
+// CalcIteration is not given.
 type Legacy struct {
 	Settings
 }
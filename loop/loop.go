@@ -7,12 +7,38 @@ type Loop interface {
 	CalcPos(pos int, length int) (int, bool)
 }
 
+// IterationCounter is an interface for loops that can report how many times they have looped
+type IterationCounter interface {
+	CalcIteration(pos int) int
+}
+
 // Settings is details about a loop
 type Settings struct {
 	Begin int
 	End   int
 }
 
+// SettingsProvider is an interface for loops that can report their loop settings
+type SettingsProvider interface {
+	GetSettings() Settings
+}
+
+// SettingsEditor is an interface for loops that can have their loop settings changed
+type SettingsEditor interface {
+	SettingsProvider
+	SetSettings(settings Settings)
+}
+
+// GetSettings returns the loop settings
+func (s Settings) GetSettings() Settings {
+	return s
+}
+
+// SetSettings replaces the loop settings
+func (s *Settings) SetSettings(settings Settings) {
+	*s = settings
+}
+
 // CalcLoopPos returns the new location and looped flag within a pair of loops (normal and sustain)
 func CalcLoopPos(loop Loop, sustain Loop, pos int, length int, keyOn bool) (int, bool) {
 	if keyOn && sustain.Enabled() {
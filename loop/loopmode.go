@@ -25,6 +25,12 @@ const (
 	//  |-------------|loopBegin>----<loopEnd|------------| <= only if looped and on playthrough 2+, part that loops plays and ping-pongs
 	//  |-------------|loopBegin>----------------------end| <= on playthrough 2+, the loop ends and playback continues to end, if !keyOn
 	ModePingPong
+
+	// ModeCrossfade is for normal looping with a crossfade into loopBegin:
+	//  |start>--------------<xfade>--loopEnd|------------| <= on playthrough 1, whole sample plays, already blended if looped (as with baked data)
+	//  |-------------|loopBegin>---<xfade>--|------------| <= only if looped and on playthrough 2+, only the part that loops plays
+	//  |-------------|loopBegin>----------------------end| <= on playthrough 2+, the loop ends and playback continues to end, if !keyOn
+	ModeCrossfade
 )
 
 // simple helper to consolidate loop length calculations
@@ -34,6 +40,8 @@ func calcLoopLen(loopBegin int, loopEnd int) int {
 }
 
 // NewLoop creates a loop based on the specified mode and settings
+// The settings are used as they are and an unknown mode panics, so settings that come from
+// outside of the program (e.g. a file) should go through NewValidatedLoop instead.
 func NewLoop(mode Mode, settings Settings) Loop {
 	switch mode {
 	case ModeDisabled:
@@ -50,6 +58,11 @@ func NewLoop(mode Mode, settings Settings) Loop {
 		return &PingPong{
 			Settings: settings,
 		}
+	case ModeCrossfade:
+		return &Crossfade{
+			Settings: settings,
+			Fade:     DefaultCrossfadeLength,
+		}
 	default:
 		panic("unhandled loop mode")
 	}
@@ -0,0 +1,239 @@
+package loop
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrUnknownName is for when a name in the text form of a loop is not recognized
+	ErrUnknownName = errors.New("unknown loop name")
+	// ErrUnserializableLoop is for when a loop is of a type that has no text form
+	ErrUnserializableLoop = errors.New("loop type cannot be serialized")
+	// ErrInvalidDefinition is for when the text form of a loop has fields that conflict or do not apply to its mode
+	ErrInvalidDefinition = errors.New("invalid loop definition")
+)
+
+var modeNames = []string{
+	ModeDisabled:  "disabled",
+	ModeLegacy:    "legacy",
+	ModeNormal:    "normal",
+	ModePingPong:  "pingpong",
+	ModeCrossfade: "crossfade",
+}
+
+var crossfadeCurveNames = []string{
+	CrossfadeCurveLinear:     "linear",
+	CrossfadeCurveEqualPower: "equal-power",
+}
+
+var pingPongEndpointsNames = []string{
+	PingPongDuplicateBoth:  "both",
+	PingPongDuplicateNone:  "none",
+	PingPongDuplicateBegin: "begin",
+	PingPongDuplicateEnd:   "end",
+}
+
+// marshalName returns the name of the value from the names
+func marshalName[T ~uint8](v T, names []string) ([]byte, error) {
+	if int(v) >= len(names) {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownName, v)
+	}
+	return []byte(names[v]), nil
+}
+
+// unmarshalName returns the value with the name from the names
+func unmarshalName[T ~uint8](text []byte, names []string) (T, error) {
+	for i, name := range names {
+		if string(text) == name {
+			return T(i), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", ErrUnknownName, text)
+}
+
+// MarshalText returns the name of the mode
+func (m Mode) MarshalText() ([]byte, error) {
+	return marshalName(m, modeNames)
+}
+
+// UnmarshalText sets the mode from its name
+func (m *Mode) UnmarshalText(text []byte) error {
+	v, err := unmarshalName[Mode](text, modeNames)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// MarshalText returns the name of the crossfade curve
+func (c CrossfadeCurve) MarshalText() ([]byte, error) {
+	return marshalName(c, crossfadeCurveNames)
+}
+
+// UnmarshalText sets the crossfade curve from its name
+func (c *CrossfadeCurve) UnmarshalText(text []byte) error {
+	v, err := unmarshalName[CrossfadeCurve](text, crossfadeCurveNames)
+	if err != nil {
+		return err
+	}
+	*c = v
+	return nil
+}
+
+// MarshalText returns the name of the ping-pong endpoints
+func (p PingPongEndpoints) MarshalText() ([]byte, error) {
+	return marshalName(p, pingPongEndpointsNames)
+}
+
+// UnmarshalText sets the ping-pong endpoints from their name
+func (p *PingPongEndpoints) UnmarshalText(text []byte) error {
+	v, err := unmarshalName[PingPongEndpoints](text, pingPongEndpointsNames)
+	if err != nil {
+		return err
+	}
+	*p = v
+	return nil
+}
+
+// Definition is the text (JSON/YAML) form of a loop. Fields that do not apply to the mode are left empty.
+type Definition struct {
+	Mode       Mode                `json:"mode" yaml:"mode"`
+	Begin      int                 `json:"begin" yaml:"begin"`
+	End        int                 `json:"end" yaml:"end"`
+	Count      *int                `json:"count,omitempty" yaml:"count,omitempty"`           // ModeNormal: repeat only this many times (Counted)
+	Fractional *FractionalSettings `json:"fractional,omitempty" yaml:"fractional,omitempty"` // ModeNormal: sub-sample loop points (Fractional)
+	Fade       int                 `json:"fade,omitempty" yaml:"fade,omitempty"`             // ModeCrossfade: 0 for DefaultCrossfadeLength
+	Curve      CrossfadeCurve      `json:"curve,omitempty" yaml:"curve,omitempty"`           // ModeCrossfade
+	Endpoints  PingPongEndpoints   `json:"endpoints,omitempty" yaml:"endpoints,omitempty"`   // ModePingPong
+}
+
+// DefinitionOf returns the text form of the loop. A nil loop is treated as a disabled one.
+func DefinitionOf(l Loop) (Definition, error) {
+	switch v := l.(type) {
+	case nil, *Disabled:
+		return Definition{
+			Mode: ModeDisabled,
+		}, nil
+	case *Legacy:
+		return Definition{
+			Mode:  ModeLegacy,
+			Begin: v.Begin,
+			End:   v.End,
+		}, nil
+	case *Normal:
+		return Definition{
+			Mode:  ModeNormal,
+			Begin: v.Begin,
+			End:   v.End,
+		}, nil
+	case *Counted:
+		count := v.Count
+		return Definition{
+			Mode:  ModeNormal,
+			Begin: v.Begin,
+			End:   v.End,
+			Count: &count,
+		}, nil
+	case *Fractional:
+		settings := v.FractionalSettings
+		return Definition{
+			Mode:       ModeNormal,
+			Fractional: &settings,
+		}, nil
+	case *PingPong:
+		return Definition{
+			Mode:      ModePingPong,
+			Begin:     v.Begin,
+			End:       v.End,
+			Endpoints: v.Endpoints,
+		}, nil
+	case *Crossfade:
+		if v.Fade <= 0 {
+			// a crossfade with no fade plays the same as a normal loop, and a fade of 0 in the
+			// text form means the default length
+			return Definition{
+				Mode:  ModeNormal,
+				Begin: v.Begin,
+				End:   v.End,
+			}, nil
+		}
+		return Definition{
+			Mode:  ModeCrossfade,
+			Begin: v.Begin,
+			End:   v.End,
+			Fade:  v.Fade,
+			Curve: v.Curve,
+		}, nil
+	default:
+		return Definition{}, fmt.Errorf("%w: %T", ErrUnserializableLoop, l)
+	}
+}
+
+// Loop creates the loop from its text form, after checking the settings against the length of
+// the data being looped (see NewValidatedLoop). The settings are not checked when the mode is ModeDisabled.
+// A count or fractional loop points are only allowed (one or the other) with ModeNormal, and a
+// crossfade with no fade length uses DefaultCrossfadeLength.
+func (d Definition) Loop(length int) (Loop, error) {
+	if !d.Mode.IsValid() {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownMode, d.Mode)
+	}
+	switch {
+	case d.Count != nil && d.Mode != ModeNormal:
+		return nil, fmt.Errorf("%w: count with mode %s", ErrInvalidDefinition, modeNames[d.Mode])
+	case d.Fractional != nil && d.Mode != ModeNormal:
+		return nil, fmt.Errorf("%w: fractional with mode %s", ErrInvalidDefinition, modeNames[d.Mode])
+	case d.Count != nil && d.Fractional != nil:
+		return nil, fmt.Errorf("%w: count with fractional", ErrInvalidDefinition)
+	case d.Count != nil && *d.Count < 0:
+		return nil, fmt.Errorf("%w: count %d", ErrInvalidDefinition, *d.Count)
+	case d.Fade < 0:
+		return nil, fmt.Errorf("%w: fade %d", ErrInvalidDefinition, d.Fade)
+	}
+
+	if d.Fractional != nil {
+		if err := d.Fractional.Validate(length); err != nil {
+			return nil, err
+		}
+		return &Fractional{
+			FractionalSettings: *d.Fractional,
+		}, nil
+	}
+
+	settings := Settings{
+		Begin: d.Begin,
+		End:   d.End,
+	}
+	if d.Mode != ModeDisabled {
+		if err := settings.Validate(length); err != nil {
+			return nil, err
+		}
+	}
+
+	switch d.Mode {
+	case ModeNormal:
+		if d.Count != nil {
+			return &Counted{
+				Settings: settings,
+				Count:    *d.Count,
+			}, nil
+		}
+	case ModePingPong:
+		return &PingPong{
+			Settings:  settings,
+			Endpoints: d.Endpoints,
+		}, nil
+	case ModeCrossfade:
+		fade := d.Fade
+		if fade == 0 {
+			fade = DefaultCrossfadeLength
+		}
+		return &Crossfade{
+			Settings: settings,
+			Fade:     fade,
+			Curve:    d.Curve,
+		}, nil
+	}
+	return NewLoop(d.Mode, settings), nil
+}
@@ -0,0 +1,160 @@
+package loop
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+// testDefinitionLoops are loops of every kind that has a text form
+var testDefinitionLoops = []struct {
+	name string
+	loop Loop
+	json string // a fragment that the JSON form must contain
+}{
+	{"disabled", &Disabled{}, `"mode":"disabled"`},
+	{"legacy", &Legacy{Settings: Settings{Begin: 2, End: 6}}, `"mode":"legacy"`},
+	{"normal", &Normal{Settings: Settings{Begin: 2, End: 6}}, `"mode":"normal"`},
+	{"counted", &Counted{Settings: Settings{Begin: 2, End: 6}, Count: 3}, `"count":3`},
+	{"counted zero", &Counted{Settings: Settings{Begin: 2, End: 6}}, `"count":0`},
+	{"fractional", &Fractional{FractionalSettings: FractionalSettings{Begin: 1.25, End: 6.5}}, `"fractional":{"begin":1.25,"end":6.5}`},
+	{"pingpong both", &PingPong{Settings: Settings{Begin: 2, End: 6}, Endpoints: PingPongDuplicateBoth}, `"mode":"pingpong"`},
+	{"pingpong none", &PingPong{Settings: Settings{Begin: 2, End: 6}, Endpoints: PingPongDuplicateNone}, `"endpoints":"none"`},
+	{"pingpong begin", &PingPong{Settings: Settings{Begin: 2, End: 6}, Endpoints: PingPongDuplicateBegin}, `"endpoints":"begin"`},
+	{"pingpong end", &PingPong{Settings: Settings{Begin: 2, End: 6}, Endpoints: PingPongDuplicateEnd}, `"endpoints":"end"`},
+	{"crossfade linear", &Crossfade{Settings: Settings{Begin: 2, End: 6}, Fade: 3}, `"mode":"crossfade"`},
+	{"crossfade equal power", &Crossfade{Settings: Settings{Begin: 2, End: 6}, Fade: 3, Curve: CrossfadeCurveEqualPower}, `"curve":"equal-power"`},
+	{"crossfade default fade", &Crossfade{Settings: Settings{Begin: 2, End: 6}, Fade: DefaultCrossfadeLength}, `"fade":256`},
+}
+
+func TestDefinitionJSONRoundTrip(t *testing.T) {
+	for _, tc := range testDefinitionLoops {
+		def, err := DefinitionOf(tc.loop)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		data, err := json.Marshal(def)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !strings.Contains(string(data), tc.json) {
+			t.Errorf("%s: JSON %s does not contain %s", tc.name, data, tc.json)
+		}
+
+		var got Definition
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		l, err := got.Loop(8)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !reflect.DeepEqual(l, tc.loop) {
+			t.Errorf("%s: got %#v, want %#v", tc.name, l, tc.loop)
+		}
+	}
+}
+
+func TestDefinitionYAMLRoundTrip(t *testing.T) {
+	for _, tc := range testDefinitionLoops {
+		def, err := DefinitionOf(tc.loop)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		data, err := yaml.Marshal(def)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		var got Definition
+		if err := yaml.Unmarshal(data, &got); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		l, err := got.Loop(8)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !reflect.DeepEqual(l, tc.loop) {
+			t.Errorf("%s: got %#v, want %#v from\n%s", tc.name, l, tc.loop, data)
+		}
+	}
+}
+
+func TestDefinitionCrossfadeFade(t *testing.T) {
+	var def Definition
+	if err := json.Unmarshal([]byte(`{"mode":"crossfade","begin":2,"end":6}`), &def); err != nil {
+		t.Fatal(err)
+	}
+	l, err := def.Loop(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if xf, ok := l.(*Crossfade); !ok || xf.Fade != DefaultCrossfadeLength {
+		t.Errorf("got %#v, want a crossfade with the default fade", l)
+	}
+
+	// a crossfade with no fade plays the same as a normal loop
+	def, err = DefinitionOf(&Crossfade{Settings: Settings{Begin: 2, End: 6}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l, err := def.Loop(8); err != nil || !reflect.DeepEqual(l, &Normal{Settings: Settings{Begin: 2, End: 6}}) {
+		t.Errorf("got %#v (error %v), want a normal loop", l, err)
+	}
+}
+
+func TestDefinitionOfNil(t *testing.T) {
+	def, err := DefinitionOf(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if def.Mode != ModeDisabled {
+		t.Errorf("got mode %v, want disabled", def.Mode)
+	}
+}
+
+type unknownLoop struct {
+	Disabled
+}
+
+func TestDefinitionErrors(t *testing.T) {
+	if _, err := DefinitionOf(&unknownLoop{}); !errors.Is(err, ErrUnserializableLoop) {
+		t.Errorf("unknown loop type: got %v, want %v", err, ErrUnserializableLoop)
+	}
+	if _, err := (Definition{Mode: Mode(99)}).Loop(8); !errors.Is(err, ErrUnknownMode) {
+		t.Errorf("unknown mode: got %v, want %v", err, ErrUnknownMode)
+	}
+
+	tests := []struct {
+		name string
+		json string
+		err  error
+	}{
+		{"unknown mode", `{"mode":"sideways","begin":0,"end":1}`, ErrUnknownName},
+		{"unknown endpoints", `{"mode":"pingpong","begin":0,"end":1,"endpoints":"middle"}`, ErrUnknownName},
+		{"unknown curve", `{"mode":"crossfade","begin":0,"end":1,"curve":"wobbly"}`, ErrUnknownName},
+		{"past the length", `{"mode":"normal","begin":2,"end":9}`, ErrPastLength},
+		{"reversed", `{"mode":"pingpong","begin":5,"end":2}`, ErrEndBeforeBegin},
+		{"fractional past the length", `{"mode":"normal","fractional":{"begin":1,"end":8.5}}`, ErrPastLength},
+		{"fractional with pingpong", `{"mode":"pingpong","begin":7,"end":2,"fractional":{"begin":1,"end":2}}`, ErrInvalidDefinition},
+		{"fractional with crossfade", `{"mode":"crossfade","begin":2,"end":6,"fractional":{"begin":1,"end":2}}`, ErrInvalidDefinition},
+		{"fractional with count", `{"mode":"normal","count":2,"fractional":{"begin":1,"end":2}}`, ErrInvalidDefinition},
+		{"count with legacy", `{"mode":"legacy","begin":2,"end":6,"count":2}`, ErrInvalidDefinition},
+		{"negative count", `{"mode":"normal","begin":2,"end":6,"count":-1}`, ErrInvalidDefinition},
+		{"negative fade", `{"mode":"crossfade","begin":2,"end":6,"fade":-4}`, ErrInvalidDefinition},
+	}
+	for _, tc := range tests {
+		var def Definition
+		err := json.Unmarshal([]byte(tc.json), &def)
+		if err == nil {
+			_, err = def.Loop(8)
+		}
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.err)
+		}
+	}
+}
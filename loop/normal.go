@@ -41,3 +41,12 @@ func (l *Normal) CalcPos(pos int, length int) (int, bool) {
 	loopedPos := dist % loopLen
 	return l.Begin + loopedPos, true
 }
+
+// CalcIteration returns the number of completed loop iterations at the (unlooped) position
+func (l *Normal) CalcIteration(pos int) int {
+	loopLen := l.Length()
+	if pos < l.End || loopLen <= 0 {
+		return 0
+	}
+	return (pos-l.End)/loopLen + 1
+}
@@ -1,11 +1,33 @@
 package loop
 
+// PingPongEndpoints selects which of the samples at the ends of a ping-pong loop are played twice
+// when the playback direction changes
+type PingPongEndpoints uint8
+
+const (
+	// PingPongDuplicateBoth is for playing both loopEnd-1 and loopBegin twice
+	PingPongDuplicateBoth = PingPongEndpoints(iota)
+	// PingPongDuplicateNone is for playing neither endpoint twice
+	PingPongDuplicateNone
+	// PingPongDuplicateBegin is for playing only loopBegin twice
+	PingPongDuplicateBegin
+	// PingPongDuplicateEnd is for playing only loopEnd-1 twice
+	PingPongDuplicateEnd
+
+	// PingPongFT2 is the endpoint behavior of FastTracker II (XM)
+	PingPongFT2 = PingPongDuplicateBoth
+	// PingPongIT is the endpoint behavior of Impulse Tracker (IT), which is also what
+	// OpenMPT uses when playing in IT-compatible mode
+	PingPongIT = PingPongDuplicateNone
+)
+
 // PingPong is a loop that bounces forward and backward between loopBegin and loopEnd
 //  |start>-----------------------loopEnd|------------| <= on playthrough 1, whole sample plays
 //  |-------------|loopBegin>----<loopEnd|------------| <= only if looped and on playthrough 2+, part that loops plays and ping-pongs
 //  |-------------|loopBegin>----------------------end| <= on playthrough 2+, the loop ends and playback continues to end, if !keyOn
 type PingPong struct {
 	Settings
+	Endpoints PingPongEndpoints
 }
 
 // Enabled returns true if the loop is enabled
@@ -37,12 +59,53 @@ func (l *PingPong) CalcPos(pos int, length int) (int, bool) {
 		return l.Begin, true
 	}
 
+	revLen, fwdLen := l.legLengths(loopLen)
+	if revLen+fwdLen <= 0 {
+		return l.Begin, true
+	}
+
+	dist := pos - l.End
+	loopedPos := dist % (revLen + fwdLen)
+	if loopedPos < revLen {
+		// reversed pass, starting at (or just before) the last sample of the loop
+		return l.Begin + revLen - loopedPos - 1, true
+	}
+	// forward pass, starting at (or just after) the first sample of the loop
+	loopedPos -= revLen
+	return l.End - fwdLen + loopedPos, true
+}
+
+// CalcIteration returns the number of completed loop passes (in either direction) at the (unlooped) position
+func (l *PingPong) CalcIteration(pos int) int {
+	loopLen := l.Length()
+	if pos < l.End || loopLen <= 0 {
+		return 0
+	}
+
+	revLen, fwdLen := l.legLengths(loopLen)
+	if revLen+fwdLen <= 0 {
+		return 1
+	}
+
 	dist := pos - l.End
-	loopedPos := dist % loopLen
-	if times := int(dist / loopLen); (times & 1) == 0 {
-		// even loops are reversed
-		return l.End - loopedPos - 1, true
+	times := 1 + 2*(dist/(revLen+fwdLen))
+	if dist%(revLen+fwdLen) >= revLen {
+		times++
+	}
+	return times
+}
+
+// legLengths returns the number of samples played on the reversed and forward passes of the loop
+func (l *PingPong) legLengths(loopLen int) (int, int) {
+	revLen, fwdLen := loopLen, loopLen
+	switch l.Endpoints {
+	case PingPongDuplicateNone:
+		revLen--
+		fwdLen--
+	case PingPongDuplicateBegin:
+		revLen--
+	case PingPongDuplicateEnd:
+		fwdLen--
 	}
-	// odd loops are forward... or normal loop
-	return l.Begin + loopedPos, true
+	return revLen, fwdLen
 }
@@ -0,0 +1,93 @@
+package loop
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPingPongCalcPos(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoints PingPongEndpoints
+		want      []int
+	}{
+		{
+			name:      "both",
+			endpoints: PingPongDuplicateBoth,
+			want:      []int{0, 1, 2, 3, 4, 4, 3, 2, 2, 3, 4, 4, 3, 2, 2, 3, 4, 4},
+		},
+		{
+			name:      "none",
+			endpoints: PingPongDuplicateNone,
+			want:      []int{0, 1, 2, 3, 4, 3, 2, 3, 4, 3, 2, 3, 4, 3, 2, 3, 4, 3},
+		},
+		{
+			name:      "begin",
+			endpoints: PingPongDuplicateBegin,
+			want:      []int{0, 1, 2, 3, 4, 3, 2, 2, 3, 4, 3, 2, 2, 3, 4, 3, 2, 2},
+		},
+		{
+			name:      "end",
+			endpoints: PingPongDuplicateEnd,
+			want:      []int{0, 1, 2, 3, 4, 4, 3, 2, 3, 4, 4, 3, 2, 3, 4, 4, 3, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &PingPong{
+				Settings: Settings{
+					Begin: 2,
+					End:   5,
+				},
+				Endpoints: tt.endpoints,
+			}
+
+			got := make([]int, len(tt.want))
+			for i := range got {
+				pos, looped := l.CalcPos(i, 8)
+				if looped != (i >= l.End) {
+					t.Errorf("position %d: got looped %v", i, looped)
+				}
+				got[i] = pos
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPingPongSingleSampleLoop(t *testing.T) {
+	for _, endpoints := range []PingPongEndpoints{PingPongDuplicateBoth, PingPongDuplicateNone, PingPongDuplicateBegin, PingPongDuplicateEnd} {
+		l := &PingPong{
+			Settings: Settings{
+				Begin: 3,
+				End:   4,
+			},
+			Endpoints: endpoints,
+		}
+		for i := 4; i < 12; i++ {
+			if pos, _ := l.CalcPos(i, 8); pos != 3 {
+				t.Errorf("endpoints %d, position %d: got %d, want 3", endpoints, i, pos)
+			}
+		}
+	}
+}
+
+func TestPingPongCalcIteration(t *testing.T) {
+	l := &PingPong{
+		Settings: Settings{
+			Begin: 2,
+			End:   5,
+		},
+		Endpoints: PingPongDuplicateNone,
+	}
+	// each leg (back, then forth) of the loop is 2 positions long
+	want := []int{0, 0, 0, 0, 0, 1, 1, 2, 2, 3, 3}
+	for i, w := range want {
+		if got := l.CalcIteration(i); got != w {
+			t.Errorf("position %d: got iteration %d, want %d", i, got, w)
+		}
+	}
+}
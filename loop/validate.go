@@ -0,0 +1,124 @@
+package loop
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	// ErrUnknownMode is for when a loop mode is not one of the known modes
+	ErrUnknownMode = errors.New("unknown loop mode")
+	// ErrNegativePosition is for when a loop begin or end point is negative
+	ErrNegativePosition = errors.New("loop position is negative")
+	// ErrEndBeforeBegin is for when a loop end point is before its begin point
+	ErrEndBeforeBegin = errors.New("loop end is before loop begin")
+	// ErrPastLength is for when a loop begin or end point is past the length of the looped data
+	ErrPastLength = errors.New("loop position is past the end of the data")
+	// ErrInvalidPosition is for when a fractional loop point is not a finite number
+	ErrInvalidPosition = errors.New("loop position is not a finite number")
+)
+
+// IsValid returns true if the mode is one of the known modes
+func (m Mode) IsValid() bool {
+	switch m {
+	case ModeDisabled, ModeLegacy, ModeNormal, ModePingPong, ModeCrossfade:
+		return true
+	default:
+		return false
+	}
+}
+
+// Validate checks the loop settings against the length of the data being looped
+func (s Settings) Validate(length int) error {
+	switch {
+	case s.Begin < 0 || s.End < 0:
+		return fmt.Errorf("%w: begin %d, end %d", ErrNegativePosition, s.Begin, s.End)
+	case s.End < s.Begin:
+		return fmt.Errorf("%w: begin %d, end %d", ErrEndBeforeBegin, s.Begin, s.End)
+	case s.End > length:
+		return fmt.Errorf("%w: end %d, length %d", ErrPastLength, s.End, length)
+	}
+	return nil
+}
+
+// Normalize returns a copy of the loop settings that is valid for the length of the data being
+// looped, by clamping the begin and end points to the data and swapping them if they are reversed
+func (s Settings) Normalize(length int) Settings {
+	if length < 0 {
+		length = 0
+	}
+	clamp := func(v int) int {
+		switch {
+		case v < 0:
+			return 0
+		case v > length:
+			return length
+		}
+		return v
+	}
+
+	n := Settings{
+		Begin: clamp(s.Begin),
+		End:   clamp(s.End),
+	}
+	if n.End < n.Begin {
+		n.Begin, n.End = n.End, n.Begin
+	}
+	return n
+}
+
+// Validate checks the fractional loop settings against the length of the data being looped
+func (s FractionalSettings) Validate(length int) error {
+	switch {
+	case math.IsNaN(s.Begin) || math.IsInf(s.Begin, 0) || math.IsNaN(s.End) || math.IsInf(s.End, 0):
+		return fmt.Errorf("%w: begin %v, end %v", ErrInvalidPosition, s.Begin, s.End)
+	case s.Begin < 0 || s.End < 0:
+		return fmt.Errorf("%w: begin %v, end %v", ErrNegativePosition, s.Begin, s.End)
+	case s.End < s.Begin:
+		return fmt.Errorf("%w: begin %v, end %v", ErrEndBeforeBegin, s.Begin, s.End)
+	case s.End > float64(length):
+		return fmt.Errorf("%w: end %v, length %d", ErrPastLength, s.End, length)
+	}
+	return nil
+}
+
+// Normalize returns a copy of the fractional loop settings that is valid for the length of the
+// data being looped, by clamping the begin and end points to the data and swapping them if they
+// are reversed. Points that are not a number are treated as 0.
+func (s FractionalSettings) Normalize(length int) FractionalSettings {
+	l := math.Max(float64(length), 0)
+	clamp := func(v float64) float64 {
+		switch {
+		case math.IsNaN(v) || v < 0:
+			return 0
+		case v > l:
+			return l
+		}
+		return v
+	}
+
+	n := FractionalSettings{
+		Begin: clamp(s.Begin),
+		End:   clamp(s.End),
+	}
+	if n.End < n.Begin {
+		n.Begin, n.End = n.End, n.Begin
+	}
+	return n
+}
+
+// NewValidatedLoop creates a loop based on the specified mode and settings, after checking them
+// against the length of the data being looped. Unlike NewLoop, an unknown mode returns an error.
+// The settings are not checked when the mode is ModeDisabled.
+func NewValidatedLoop(mode Mode, settings Settings, length int) (Loop, error) {
+	if !mode.IsValid() {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownMode, mode)
+	}
+	if mode != ModeDisabled {
+		if err := settings.Validate(length); err != nil {
+			return nil, err
+		}
+	}
+	return NewLoop(mode, settings), nil
+}
@@ -0,0 +1,138 @@
+package loop
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestSettingsValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings Settings
+		length   int
+		err      error
+	}{
+		{"valid", Settings{Begin: 2, End: 6}, 8, nil},
+		{"whole data", Settings{Begin: 0, End: 8}, 8, nil},
+		{"empty loop", Settings{Begin: 3, End: 3}, 8, nil},
+		{"empty data", Settings{Begin: 0, End: 0}, 0, nil},
+		{"negative begin", Settings{Begin: -1, End: 4}, 8, ErrNegativePosition},
+		{"negative end", Settings{Begin: 0, End: -1}, 8, ErrNegativePosition},
+		{"reversed", Settings{Begin: 6, End: 2}, 8, ErrEndBeforeBegin},
+		{"past length", Settings{Begin: 2, End: 9}, 8, ErrPastLength},
+		{"past empty data", Settings{Begin: 0, End: 1}, 0, ErrPastLength},
+	}
+	for _, tc := range tests {
+		err := tc.settings.Validate(tc.length)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.err)
+		}
+	}
+}
+
+func TestFractionalSettingsValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings FractionalSettings
+		length   int
+		err      error
+	}{
+		{"valid", FractionalSettings{Begin: 1.5, End: 6.25}, 8, nil},
+		{"empty loop", FractionalSettings{Begin: 2.5, End: 2.5}, 8, nil},
+		{"not a number", FractionalSettings{Begin: math.NaN(), End: 4}, 8, ErrInvalidPosition},
+		{"infinite", FractionalSettings{Begin: 0, End: math.Inf(1)}, 8, ErrInvalidPosition},
+		{"negative", FractionalSettings{Begin: -0.5, End: 4}, 8, ErrNegativePosition},
+		{"reversed", FractionalSettings{Begin: 6.5, End: 2}, 8, ErrEndBeforeBegin},
+		{"past length", FractionalSettings{Begin: 2, End: 8.01}, 8, ErrPastLength},
+	}
+	for _, tc := range tests {
+		err := tc.settings.Validate(tc.length)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.err)
+		}
+	}
+}
+
+func TestSettingsNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings Settings
+		length   int
+		want     Settings
+	}{
+		{"valid", Settings{Begin: 2, End: 6}, 8, Settings{Begin: 2, End: 6}},
+		{"negative", Settings{Begin: -3, End: 4}, 8, Settings{Begin: 0, End: 4}},
+		{"reversed", Settings{Begin: 6, End: 2}, 8, Settings{Begin: 2, End: 6}},
+		{"past length", Settings{Begin: 2, End: 12}, 8, Settings{Begin: 2, End: 8}},
+		{"reversed past length", Settings{Begin: 12, End: 3}, 8, Settings{Begin: 3, End: 8}},
+		{"negative length", Settings{Begin: 2, End: 6}, -1, Settings{Begin: 0, End: 0}},
+	}
+	for _, tc := range tests {
+		got := tc.settings.Normalize(tc.length)
+		if got != tc.want {
+			t.Errorf("%s: got %+v, want %+v", tc.name, got, tc.want)
+		}
+		if err := got.Validate(tc.length); tc.length >= 0 && err != nil {
+			t.Errorf("%s: normalized settings are not valid: %v", tc.name, err)
+		}
+	}
+}
+
+func TestFractionalSettingsNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings FractionalSettings
+		length   int
+		want     FractionalSettings
+	}{
+		{"valid", FractionalSettings{Begin: 1.5, End: 6.25}, 8, FractionalSettings{Begin: 1.5, End: 6.25}},
+		{"not a number", FractionalSettings{Begin: math.NaN(), End: 4}, 8, FractionalSettings{Begin: 0, End: 4}},
+		{"infinite", FractionalSettings{Begin: 2, End: math.Inf(1)}, 8, FractionalSettings{Begin: 2, End: 8}},
+		{"reversed", FractionalSettings{Begin: 6.5, End: 2}, 8, FractionalSettings{Begin: 2, End: 6.5}},
+		{"negative", FractionalSettings{Begin: -1, End: 2}, 8, FractionalSettings{Begin: 0, End: 2}},
+	}
+	for _, tc := range tests {
+		got := tc.settings.Normalize(tc.length)
+		if got != tc.want {
+			t.Errorf("%s: got %+v, want %+v", tc.name, got, tc.want)
+		}
+		if err := got.Validate(tc.length); err != nil {
+			t.Errorf("%s: normalized settings are not valid: %v", tc.name, err)
+		}
+	}
+}
+
+func TestNewValidatedLoop(t *testing.T) {
+	valid := Settings{Begin: 2, End: 6}
+	tests := []struct {
+		name     string
+		mode     Mode
+		settings Settings
+		want     Loop
+		err      error
+	}{
+		{"disabled", ModeDisabled, valid, &Disabled{}, nil},
+		{"disabled ignores settings", ModeDisabled, Settings{Begin: 6, End: 2}, &Disabled{}, nil},
+		{"legacy", ModeLegacy, valid, &Legacy{Settings: valid}, nil},
+		{"normal", ModeNormal, valid, &Normal{Settings: valid}, nil},
+		{"pingpong", ModePingPong, valid, &PingPong{Settings: valid}, nil},
+		{"crossfade", ModeCrossfade, valid, &Crossfade{Settings: valid, Fade: DefaultCrossfadeLength}, nil},
+		{"empty", ModeNormal, Settings{Begin: 4, End: 4}, &Normal{Settings: Settings{Begin: 4, End: 4}}, nil},
+		{"unknown mode", Mode(99), valid, nil, ErrUnknownMode},
+		{"negative", ModeNormal, Settings{Begin: -1, End: 4}, nil, ErrNegativePosition},
+		{"reversed", ModePingPong, Settings{Begin: 6, End: 2}, nil, ErrEndBeforeBegin},
+		{"past length", ModeLegacy, Settings{Begin: 2, End: 9}, nil, ErrPastLength},
+	}
+	for _, tc := range tests {
+		l, err := NewValidatedLoop(tc.mode, tc.settings, 8)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: got error %v, want %v", tc.name, err, tc.err)
+			continue
+		}
+		if !reflect.DeepEqual(l, tc.want) {
+			t.Errorf("%s: got %#v, want %#v", tc.name, l, tc.want)
+		}
+	}
+}
@@ -11,3 +11,9 @@ type PanEnveloper interface {
 	GetCurrentPanEnvelope() panning.Position
 	SetPanEnvelopePosition(pos int)
 }
+
+// PanEnvelopeRamper is an interface for voices that can provide the pan envelope's value partway
+// through a tick, ramping from the previous tick's value to the current one
+type PanEnvelopeRamper interface {
+	GetPanEnvelopeAt(t float32) panning.Position
+}
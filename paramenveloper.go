@@ -0,0 +1,29 @@
+package voice
+
+import "time"
+
+// ParamEnvelope is an envelope that drives a voice parameter beyond the volume, pan, pitch
+// and filter envelopes (e.g. sample start offset, resonance, or wavetable position)
+type ParamEnvelope interface {
+	SetEnabled(enabled bool)
+	IsEnabled() bool
+	Restart()
+	SetEnvelopePosition(pos int) Callback
+	Advance(keyOn bool, prevKeyOn bool) Callback
+	AdvanceTime(keyOn bool, prevKeyOn bool, elapsed time.Duration) Callback
+}
+
+// ParamEnvelopeValue is a ParamEnvelope that provides the value of type V that it drives the parameter with
+type ParamEnvelopeValue[V any] interface {
+	ParamEnvelope
+	GetCurrentValue() V
+	GetValueAt(t float32) V
+}
+
+// ParamEnveloper is an interface for registering additional envelope-driven parameters on a voice
+type ParamEnveloper interface {
+	RegisterParamEnvelope(name string, env ParamEnvelope)
+	UnregisterParamEnvelope(name string)
+	GetParamEnvelope(name string) ParamEnvelope
+	GetParamEnvelopeNames() []string
+}
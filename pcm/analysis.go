@@ -0,0 +1,129 @@
+package pcm
+
+import (
+	"math"
+
+	"github.com/gotracker/gomixing/volume"
+)
+
+// ChannelStats is the result of analyzing a single channel of a sample
+type ChannelStats struct {
+	Peak     volume.Volume // largest absolute value
+	RMS      volume.Volume // root-mean-square level
+	DCOffset volume.Volume // mean value
+}
+
+// Analyze computes the peak, RMS and DC offset of each channel of the sample
+func Analyze(s Sample) ([]ChannelStats, error) {
+	frames, err := readFrames(s)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]ChannelStats, len(frames))
+	for c, data := range frames {
+		stats[c] = analyzeChannel(data)
+	}
+	return stats, nil
+}
+
+func analyzeChannel(data []float64) ChannelStats {
+	if len(data) == 0 {
+		return ChannelStats{}
+	}
+
+	var peak, sum, sumSq float64
+	for _, v := range data {
+		peak = math.Max(peak, math.Abs(v))
+		sum += v
+		sumSq += v * v
+	}
+	n := float64(len(data))
+	return ChannelStats{
+		Peak:     volume.Volume(peak),
+		RMS:      volume.Volume(math.Sqrt(sumSq / n)),
+		DCOffset: volume.Volume(sum / n),
+	}
+}
+
+// RemoveDC returns a new sample in the requested format with the DC offset of each channel removed
+func RemoveDC(s Sample, format SampleDataFormat) (Sample, error) {
+	frames, err := readFrames(s)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, data := range frames {
+		dc := float64(analyzeChannel(data).DCOffset)
+		for i := range data {
+			data[i] -= dc
+		}
+	}
+	return ConvertTo(newSampleFromFrames(frames, s.Length()), format)
+}
+
+// Normalize returns a new sample in the requested format with the gain adjusted so that the
+// loudest channel peaks at `peak`. Channel balance is preserved and silent samples are unchanged.
+func Normalize(s Sample, peak volume.Volume, format SampleDataFormat) (Sample, error) {
+	frames, err := readFrames(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var maxPeak float64
+	for _, data := range frames {
+		maxPeak = math.Max(maxPeak, float64(analyzeChannel(data).Peak))
+	}
+
+	if maxPeak > 0 {
+		gain := float64(peak) / maxPeak
+		for _, data := range frames {
+			for i := range data {
+				data[i] *= gain
+			}
+		}
+	}
+	return ConvertTo(newSampleFromFrames(frames, s.Length()), format)
+}
+
+// TrimSilence returns a view of the sample with the leading and trailing frames removed where
+// every channel's absolute value is at or below `threshold`. The frame offset of the view within
+// the original sample is also returned, so that loop points can be adjusted by it.
+func TrimSilence(s Sample, threshold volume.Volume) (Sample, int, error) {
+	frames, err := readFrames(s)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	thresh := math.Abs(float64(threshold))
+	silent := func(i int) bool {
+		for _, data := range frames {
+			if math.Abs(data[i]) > thresh {
+				return false
+			}
+		}
+		return true
+	}
+
+	length := s.Length()
+	begin := 0
+	for begin < length && silent(begin) {
+		begin++
+	}
+	if begin == length {
+		// everything is silent
+		v, err := NewSampleView(s, 0, 0)
+		return v, 0, err
+	}
+
+	end := length
+	for end > begin && silent(end-1) {
+		end--
+	}
+
+	v, err := NewSampleView(s, begin, end-begin)
+	if err != nil {
+		return nil, 0, err
+	}
+	return v, begin, nil
+}
@@ -0,0 +1,206 @@
+package pcm
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/gotracker/gomixing/volume"
+)
+
+// readAll returns the values of every frame of the sample, by channel
+func readAll(t *testing.T, s Sample) [][]float64 {
+	t.Helper()
+	frames, err := readFrames(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return frames
+}
+
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames [][]float64
+		want   []ChannelStats
+	}{
+		{
+			// a square wave with a DC offset, and a silent channel
+			name: "dc offset",
+			frames: [][]float64{
+				{0.75, -0.25, 0.75, -0.25},
+				{0, 0, 0, 0},
+			},
+			want: []ChannelStats{
+				{Peak: 0.75, RMS: volume.Volume(math.Sqrt(0.3125)), DCOffset: 0.25},
+				{},
+			},
+		},
+		{
+			name:   "negative peak",
+			frames: [][]float64{{0.1, -0.9, 0.2}},
+			want:   []ChannelStats{{Peak: 0.9, RMS: volume.Volume(math.Sqrt(0.86 / 3)), DCOffset: -0.2}},
+		},
+		{
+			name:   "no frames",
+			frames: [][]float64{nil, nil},
+			want:   []ChannelStats{{}, {}},
+		},
+	}
+	for _, tc := range tests {
+		got, err := Analyze(newSampleFromFrames(tc.frames, len(tc.frames[0])))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if len(got) != len(tc.want) {
+			t.Fatalf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+		for c, w := range tc.want {
+			g := got[c]
+			if !closeTo(float64(g.Peak), float64(w.Peak)) || !closeTo(float64(g.RMS), float64(w.RMS)) || !closeTo(float64(g.DCOffset), float64(w.DCOffset)) {
+				t.Errorf("%s: channel %d: got %+v, want %+v", tc.name, c, g, w)
+			}
+		}
+	}
+}
+
+func TestRemoveDC(t *testing.T) {
+	src := newSampleFromFrames([][]float64{
+		{0.75, -0.25, 0.75, -0.25},
+		{0, 0, 0, 0},
+		{-0.5, -0.5, -0.5, -0.5},
+	}, 4)
+	out, err := RemoveDC(src, SampleDataFormat64BitLEFloat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]float64{
+		{0.5, -0.5, 0.5, -0.5},
+		{0, 0, 0, 0},
+		{0, 0, 0, 0},
+	}
+	if got := readAll(t, out); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames [][]float64
+		peak   volume.Volume
+		want   [][]float64
+	}{
+		{
+			// the balance between the channels is kept
+			name:   "stereo",
+			frames: [][]float64{{0.5, -0.25}, {0.25, 0.125}},
+			peak:   0.9,
+			want:   [][]float64{{0.9, -0.45}, {0.45, 0.225}},
+		},
+		{
+			name:   "negative peak",
+			frames: [][]float64{{-0.8, 0.2}},
+			peak:   1,
+			want:   [][]float64{{-1, 0.25}},
+		},
+		{
+			name:   "reduce",
+			frames: [][]float64{{2, -1}},
+			peak:   0.5,
+			want:   [][]float64{{0.5, -0.25}},
+		},
+		{
+			name:   "all zero",
+			frames: [][]float64{{0, 0, 0}},
+			peak:   1,
+			want:   [][]float64{{0, 0, 0}},
+		},
+	}
+	for _, tc := range tests {
+		out, err := Normalize(newSampleFromFrames(tc.frames, len(tc.frames[0])), tc.peak, SampleDataFormat64BitLEFloat)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		got := readAll(t, out)
+		for c := range tc.want {
+			for i, w := range tc.want[c] {
+				if !closeTo(got[c][i], w) {
+					t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestTrimSilence(t *testing.T) {
+	tests := []struct {
+		name       string
+		frames     [][]float64
+		threshold  volume.Volume
+		wantOffset int
+		want       [][]float64
+	}{
+		{
+			// values at the threshold are silent, but silence within the sound is kept
+			name:       "mono",
+			frames:     [][]float64{{0, 0.01, 0.5, 0, -0.3, 0.005, 0}},
+			threshold:  0.01,
+			wantOffset: 2,
+			want:       [][]float64{{0.5, 0, -0.3}},
+		},
+		{
+			// a frame is only silent if every channel is
+			name: "stereo",
+			frames: [][]float64{
+				{0, 0, 0.5, 0, 0},
+				{0, 0.5, 0, 0, 0},
+			},
+			wantOffset: 1,
+			want: [][]float64{
+				{0, 0.5},
+				{0.5, 0},
+			},
+		},
+		{
+			name:       "negative threshold",
+			frames:     [][]float64{{0.1, 0.3, -0.1}},
+			threshold:  -0.2,
+			wantOffset: 1,
+			want:       [][]float64{{0.3}},
+		},
+		{
+			name:   "no silence",
+			frames: [][]float64{{0.1, 0.2}},
+			want:   [][]float64{{0.1, 0.2}},
+		},
+		{
+			name:   "all zero",
+			frames: [][]float64{{0, 0, 0}, {0, 0, 0}},
+			want:   [][]float64{{}, {}},
+		},
+		{
+			name:      "all below the threshold",
+			frames:    [][]float64{{0.1, -0.1}},
+			threshold: 0.5,
+			want:      [][]float64{{}},
+		},
+	}
+	for _, tc := range tests {
+		v, offset, err := TrimSilence(newSampleFromFrames(tc.frames, len(tc.frames[0])), tc.threshold)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if offset != tc.wantOffset {
+			t.Errorf("%s: got offset %d, want %d", tc.name, offset, tc.wantOffset)
+		}
+		if got := readAll(t, v); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package pcm
+
+import (
+	"github.com/gotracker/voice/loop"
+)
+
+// BakeCrossfade returns a new sample in the requested format with the crossfade of the loop applied
+// to the sample data, so that it can be played back with a plain loop.Normal of the same settings
+func BakeCrossfade(s Sample, l *loop.Crossfade, format SampleDataFormat) (Sample, error) {
+	frames, err := readFrames(s)
+	if err != nil {
+		return nil, err
+	}
+
+	length := s.Length()
+	out := make([][]float64, len(frames))
+	for c, data := range frames {
+		out[c] = append([]float64(nil), data...)
+		for pos := l.End - l.CrossfadeLength(); pos < l.End && pos < length; pos++ {
+			other, gain, otherGain, blend := l.CalcCrossfade(pos)
+			if !blend || pos < 0 || other < 0 {
+				continue
+			}
+			out[c][pos] = data[pos]*float64(gain) + data[other]*float64(otherGain)
+		}
+	}
+	return ConvertTo(newSampleFromFrames(out, length), format)
+}
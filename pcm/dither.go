@@ -0,0 +1,76 @@
+package pcm
+
+import (
+	"math"
+	"math/rand"
+)
+
+// DitherMode is the mode of dithering applied when reducing the bit depth of sample data
+type DitherMode uint8
+
+const (
+	// DitherModeNone is for when no dither is applied (values are rounded to the nearest step)
+	DitherModeNone = DitherMode(iota)
+	// DitherModeTPDF is for triangular probability density function dither
+	DitherModeTPDF
+	// DitherModeNoiseShaped is for TPDF dither with first-order error feedback noise shaping
+	DitherModeNoiseShaped
+)
+
+// ditherSeed keeps conversions deterministic so the same input always produces the same output
+const ditherSeed = 0x5eed
+
+type ditherer struct {
+	mode DitherMode
+	rng  *rand.Rand
+	err  []float64 // per-channel quantization error (noise shaping)
+}
+
+func newDitherer(mode DitherMode, channels int) *ditherer {
+	d := ditherer{
+		mode: mode,
+	}
+	if mode != DitherModeNone {
+		d.rng = rand.New(rand.NewSource(ditherSeed))
+	}
+	if mode == DitherModeNoiseShaped {
+		d.err = make([]float64, channels)
+	}
+	return &d
+}
+
+// quantize converts the value `v` (nominally in the range [-1, 1)) to a signed integer
+// of `scale` steps per unit, applying dither and saturating to [-scale, scale-1]
+func (d *ditherer) quantize(ch int, v float64, scale float64) int64 {
+	x := v * scale
+	if d.err != nil {
+		x -= d.err[ch]
+	}
+
+	q := x
+	if d.rng != nil {
+		// TPDF noise with an amplitude of +/- 1 LSB
+		q += d.rng.Float64() - d.rng.Float64()
+	}
+	q = math.Round(q)
+
+	switch {
+	case q < -scale:
+		q = -scale
+	case q > scale-1:
+		q = scale - 1
+	}
+
+	if d.err != nil {
+		// keep the feedback bounded when the output saturates
+		e := q - x
+		switch {
+		case e < -1:
+			e = -1
+		case e > 1:
+			e = 1
+		}
+		d.err[ch] = e
+	}
+	return int64(q)
+}
@@ -25,3 +25,36 @@ const (
 	// SampleDataFormat64BitBEFloat is for big-endian, 64-bit floating-point data
 	SampleDataFormat64BitBEFloat
 )
+
+// formatBitDepth returns the effective resolution (in bits) of the sample data format
+func formatBitDepth(format SampleDataFormat) (int, bool) {
+	switch format {
+	case SampleDataFormat8BitUnsigned, SampleDataFormat8BitSigned:
+		return 8, true
+	case SampleDataFormat16BitLEUnsigned, SampleDataFormat16BitLESigned,
+		SampleDataFormat16BitBEUnsigned, SampleDataFormat16BitBESigned:
+		return 16, true
+	case SampleDataFormat32BitLEFloat, SampleDataFormat32BitBEFloat:
+		return 32, true
+	case SampleDataFormat64BitLEFloat, SampleDataFormat64BitBEFloat:
+		return 64, true
+	default:
+		return 0, false
+	}
+}
+
+// sampleBitDepth returns the effective resolution (in bits) of the data backing a sample
+func sampleBitDepth(s Sample) int {
+	switch v := s.(type) {
+	case *SampleView:
+		return sampleBitDepth(v.src)
+	case *PCMReader[Sample8BitSigned], *PCMReader[Sample8BitUnsigned]:
+		return 8
+	case *PCMReader[Sample16BitSigned], *PCMReader[Sample16BitUnsigned]:
+		return 16
+	case *PCMReader[Sample32BitFloat]:
+		return 32
+	default:
+		return 64
+	}
+}
@@ -0,0 +1,41 @@
+package pcm
+
+import (
+	"github.com/gotracker/gomixing/volume"
+)
+
+// readFrames reads the whole of the sample into per-channel buffers
+func readFrames(s Sample) ([][]float64, error) {
+	length := s.Length()
+	channels := s.Channels()
+
+	frames := make([][]float64, channels)
+	for c := range frames {
+		frames[c] = make([]float64, length)
+	}
+
+	s.Seek(0)
+	for i := 0; i < length; i++ {
+		samp, err := s.Read()
+		if err != nil {
+			return nil, err
+		}
+		for c := 0; c < channels && c < samp.Channels; c++ {
+			frames[c][i] = float64(samp.StaticMatrix[c])
+		}
+	}
+	return frames, nil
+}
+
+// newSampleFromFrames creates a native sample from per-channel buffers
+func newSampleFromFrames(frames [][]float64, length int) Sample {
+	channels := len(frames)
+	data := make([]volume.Matrix, length)
+	for i := range data {
+		data[i].Channels = channels
+		for c := 0; c < channels; c++ {
+			data[i].StaticMatrix[c] = volume.Volume(frames[c][i])
+		}
+	}
+	return NewSampleNative(data, length, channels)
+}
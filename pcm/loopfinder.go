@@ -0,0 +1,151 @@
+package pcm
+
+import (
+	"math"
+	"sort"
+
+	"github.com/gotracker/voice/loop"
+)
+
+const (
+	defaultLoopSearchRadius     = 256
+	defaultLoopSearchWindow     = 32
+	defaultLoopSearchMaxResults = 8
+
+	loopScoreEpsilon = 1e-9
+)
+
+// LoopSearch is the region and parameters used when searching for loop points
+type LoopSearch struct {
+	loop.Settings     // rough loop begin and end points
+	Radius        int // distance (in frames) to search around the begin and end points
+	Window        int // number of frames compared on either side of the loop boundary
+	MaxResults    int // maximum number of candidates to return
+}
+
+// LoopCandidate is a loop found by FindLoopPoints
+type LoopCandidate struct {
+	loop.Settings
+	Score float64 // discontinuity score (mean squared difference across the boundary), lower is better
+}
+
+// FindLoopPoints searches the sample near the rough loop points in `search` for zero crossings
+// whose surrounding waveforms best match each other, returning candidate loop settings ranked by
+// their discontinuity score (best first). Zero values in `search` select default parameters.
+func FindLoopPoints(s Sample, search LoopSearch) ([]LoopCandidate, error) {
+	radius := search.Radius
+	if radius <= 0 {
+		radius = defaultLoopSearchRadius
+	}
+	window := search.Window
+	if window <= 0 {
+		window = defaultLoopSearchWindow
+	}
+	maxResults := search.MaxResults
+	if maxResults <= 0 {
+		maxResults = defaultLoopSearchMaxResults
+	}
+
+	frames, err := readFrames(s)
+	if err != nil {
+		return nil, err
+	}
+	length := s.Length()
+
+	mono := make([]float64, length)
+	for _, data := range frames {
+		for i, v := range data {
+			mono[i] += v
+		}
+	}
+
+	begins := findZeroCrossings(mono, search.Begin-radius, search.Begin+radius)
+	ends := findZeroCrossings(mono, search.End-radius, search.End+radius)
+
+	var candidates []LoopCandidate
+	for _, b := range begins {
+		for _, e := range ends {
+			if e.pos <= b.pos || e.rising != b.rising {
+				continue
+			}
+			candidates = append(candidates, LoopCandidate{
+				Settings: loop.Settings{
+					Begin: b.pos,
+					End:   e.pos,
+				},
+				Score: loopDiscontinuity(frames, b.pos, e.pos, window),
+			})
+		}
+	}
+
+	// rank by score, preferring candidates closest to the requested points when scores match
+	distance := func(c LoopCandidate) int {
+		return abs(c.Begin-search.Begin) + abs(c.End-search.End)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ci, cj := candidates[i], candidates[j]
+		if math.Abs(ci.Score-cj.Score) > loopScoreEpsilon {
+			return ci.Score < cj.Score
+		}
+		return distance(ci) < distance(cj)
+	})
+	if len(candidates) > maxResults {
+		candidates = candidates[:maxResults]
+	}
+	return candidates, nil
+}
+
+type zeroCrossing struct {
+	pos    int
+	rising bool
+}
+
+// findZeroCrossings returns the positions in [from, to] where the data crosses (or touches) zero
+// between the previous frame and the current one
+func findZeroCrossings(data []float64, from int, to int) []zeroCrossing {
+	if from < 1 {
+		from = 1
+	}
+	if to > len(data) {
+		// the loop end may sit just past the last frame
+		to = len(data)
+	}
+
+	var crossings []zeroCrossing
+	for i := from; i <= to; i++ {
+		prev := data[i-1]
+		cur := frameAt(data, i)
+		switch {
+		case prev < 0 && cur >= 0:
+			crossings = append(crossings, zeroCrossing{pos: i, rising: true})
+		case prev > 0 && cur <= 0:
+			crossings = append(crossings, zeroCrossing{pos: i, rising: false})
+		}
+	}
+	return crossings
+}
+
+// loopDiscontinuity compares the frames leading up to (and following) the loop end with those
+// around the loop begin, which is what playback will hear when the loop wraps around
+func loopDiscontinuity(frames [][]float64, begin int, end int, window int) float64 {
+	var sum float64
+	var n int
+	for _, data := range frames {
+		for k := -window; k < window; k++ {
+			d := frameAt(data, end+k) - frameAt(data, begin+k)
+			sum += d * d
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,148 @@
+package pcm
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/gotracker/voice/loop"
+)
+
+// newLoopFinderSample returns a mono sample of the sum of sine waves with the periods (in frames).
+// The waves are offset by half a frame, so that no frame lands exactly on a zero crossing.
+func newLoopFinderSample(length int, periods ...float64) Sample {
+	data := make([]float64, length)
+	for i := range data {
+		for n, p := range periods {
+			data[i] += math.Sin(2*math.Pi*(float64(i)+0.5)/p) / float64(n+1)
+		}
+	}
+	return newSampleFromFrames([][]float64{data}, length)
+}
+
+func TestFindLoopPointsTieBreak(t *testing.T) {
+	// every crossing of a sine with a period of 32 frames makes a seamless loop, so the candidates
+	// all score 0 and are ranked by how close they are to the requested points
+	s := newLoopFinderSample(512, 32)
+	got, err := FindLoopPoints(s, LoopSearch{
+		Settings: loop.Settings{Begin: 100, End: 300},
+		Radius:   20,
+		Window:   8,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []loop.Settings{
+		{Begin: 96, End: 288},  // rising, 16 frames away
+		{Begin: 112, End: 304}, // falling, 16 frames away
+		{Begin: 80, End: 304},  // falling, 24 frames away
+		{Begin: 96, End: 320},  // rising, 24 frames away
+	}
+	var gotSettings []loop.Settings
+	for _, c := range got {
+		gotSettings = append(gotSettings, c.Settings)
+		if c.Score > 1e-12 {
+			t.Errorf("%v: got score %v, want 0", c.Settings, c.Score)
+		}
+	}
+	if !reflect.DeepEqual(gotSettings, want) {
+		t.Errorf("got %v, want %v", gotSettings, want)
+	}
+}
+
+func TestFindLoopPointsRanking(t *testing.T) {
+	// the sum of sines with periods of 32 and 48 frames repeats every 96 frames, so only loops
+	// of a multiple of that length are seamless
+	s := newLoopFinderSample(1024, 32, 48)
+	got, err := FindLoopPoints(s, LoopSearch{
+		Settings:   loop.Settings{Begin: 200, End: 400},
+		Radius:     40,
+		Window:     16,
+		MaxResults: 100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) < 2 {
+		t.Fatalf("got %d candidates, want at least 2", len(got))
+	}
+
+	best := got[0]
+	if (best.End-best.Begin)%96 != 0 || best.Score > 1e-12 {
+		t.Errorf("got best %v (score %v), want a seamless loop of a multiple of 96 frames", best.Settings, best.Score)
+	}
+	var seamless, seams int
+	for i, c := range got {
+		if i > 0 && c.Score < got[i-1].Score-loopScoreEpsilon {
+			t.Errorf("candidate %d (%v) scores %v, better than the %v before it", i, c.Settings, c.Score, got[i-1].Score)
+		}
+		if c.End <= c.Begin || abs(c.Begin-200) > 40 || abs(c.End-400) > 40 {
+			t.Errorf("candidate %d (%v) is outside of the search", i, c.Settings)
+		}
+		if (c.End-c.Begin)%96 == 0 {
+			seamless++
+		} else {
+			seams++
+			if c.Score <= best.Score {
+				t.Errorf("candidate %d (%v) with a seam scores %v, no worse than the best", i, c.Settings, c.Score)
+			}
+		}
+	}
+	if seamless == 0 || seams == 0 {
+		t.Errorf("got %d seamless and %d other candidates, want some of each", seamless, seams)
+	}
+
+	limited, err := FindLoopPoints(s, LoopSearch{
+		Settings:   loop.Settings{Begin: 200, End: 400},
+		Radius:     40,
+		Window:     16,
+		MaxResults: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(limited, got[:2]) {
+		t.Errorf("limited to 2: got %v, want %v", limited, got[:2])
+	}
+}
+
+func TestFindLoopPointsDefaults(t *testing.T) {
+	s := newLoopFinderSample(4096, 32)
+	got, err := FindLoopPoints(s, LoopSearch{
+		Settings: loop.Settings{Begin: 1000, End: 3000},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != defaultLoopSearchMaxResults {
+		t.Errorf("got %d candidates, want %d", len(got), defaultLoopSearchMaxResults)
+	}
+
+	// there are no crossings in a sample with no sign changes
+	dc := newSampleFromFrames([][]float64{{0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5}}, 8)
+	got, err = FindLoopPoints(dc, LoopSearch{
+		Settings: loop.Settings{Begin: 2, End: 6},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("no crossings: got %v, want none", got)
+	}
+}
+
+func TestFindLoopPointsAtSampleEnd(t *testing.T) {
+	// the loop end can sit just past the last frame, where the sample wraps back to the begin
+	s := newLoopFinderSample(512, 32)
+	got, err := FindLoopPoints(s, LoopSearch{
+		Settings: loop.Settings{Begin: 100, End: 510},
+		Radius:   8,
+		Window:   8,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) == 0 || got[0].Settings != (loop.Settings{Begin: 96, End: 512}) {
+		t.Errorf("got %v, want {96 512} first", got)
+	}
+}
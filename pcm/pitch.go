@@ -0,0 +1,148 @@
+package pcm
+
+import (
+	"errors"
+	"math"
+
+	"github.com/gotracker/voice/period"
+)
+
+const (
+	pitchMinFrequency  = 20   // lowest fundamental frequency searched for, in Hz
+	pitchYINThreshold  = 0.15 // YIN absolute threshold for the cumulative mean normalized difference
+	pitchDefaultWindow = 0.2  // length analysed when none is given, in seconds (4 periods of pitchMinFrequency)
+)
+
+var (
+	// ErrNoPitch is for when a fundamental frequency could not be found in a sample
+	ErrNoPitch = errors.New("no pitch detected")
+)
+
+// PitchEstimate is the estimated fundamental frequency of a sample
+type PitchEstimate struct {
+	Frequency  period.Frequency // fundamental frequency when played at the sample's own rate
+	Note       int              // nearest semitone, where 60 is middle C (C-5 in tracker notation) and 69 is A-440
+	Cents      float64          // offset of the frequency from the nearest semitone, in the range [-50, 50]
+	Confidence float64          // 0 (unreliable) through 1 (perfectly periodic)
+}
+
+// DetectPitch estimates the fundamental frequency of `length` frames of the sample starting at
+// frame `begin` using the YIN algorithm. All channels are mixed down before analysis. If length is
+// zero or negative, up to 0.2 seconds of the sample after `begin` is used, as the cost of the
+// analysis grows with the length times the longest period searched for.
+func DetectPitch(s Sample, sampleRate period.Frequency, begin int, length int) (PitchEstimate, error) {
+	if !(sampleRate > 0) || math.IsInf(float64(sampleRate), 0) {
+		return PitchEstimate{}, ErrInvalidRate
+	}
+	if length <= 0 {
+		length = s.Length() - begin
+		if maxLen := int(float64(sampleRate) * pitchDefaultWindow); length > maxLen {
+			length = maxLen
+		}
+	}
+
+	region, err := NewSampleView(s, begin, length)
+	if err != nil {
+		return PitchEstimate{}, err
+	}
+
+	frames, err := readFrames(region)
+	if err != nil {
+		return PitchEstimate{}, err
+	}
+
+	mono := make([]float64, length)
+	for _, data := range frames {
+		for i, v := range data {
+			mono[i] += v
+		}
+	}
+
+	tau, confidence, ok := yin(mono, int(float64(sampleRate)/pitchMinFrequency))
+	if !ok {
+		return PitchEstimate{}, ErrNoPitch
+	}
+
+	freq := float64(sampleRate) / tau
+	semis := 69 + 12*math.Log2(freq/440)
+	note := math.Round(semis)
+	return PitchEstimate{
+		Frequency:  period.Frequency(freq),
+		Note:       int(note),
+		Cents:      (semis - note) * 100,
+		Confidence: confidence,
+	}, nil
+}
+
+// yin returns the fundamental period (in frames) of the data, with sub-frame precision
+func yin(data []float64, maxTau int) (float64, float64, bool) {
+	if maxTau > len(data)/2 {
+		maxTau = len(data) / 2
+	}
+	if maxTau < 3 {
+		return 0, 0, false
+	}
+	window := len(data) - maxTau
+
+	// cumulative mean normalized difference function
+	diff := make([]float64, maxTau+1)
+	cmnd := make([]float64, maxTau+1)
+	cmnd[0] = 1
+	var running float64
+	for tau := 1; tau <= maxTau; tau++ {
+		var d float64
+		for i := 0; i < window; i++ {
+			delta := data[i] - data[i+tau]
+			d += delta * delta
+		}
+		diff[tau] = d
+		running += d
+		if running == 0 {
+			cmnd[tau] = 1
+		} else {
+			cmnd[tau] = d * float64(tau) / running
+		}
+	}
+
+	// first dip under the threshold, falling back to the global minimum
+	best := -1
+	for tau := 2; tau < maxTau; tau++ {
+		if cmnd[tau] < pitchYINThreshold {
+			for tau+1 < maxTau && cmnd[tau+1] < cmnd[tau] {
+				tau++
+			}
+			best = tau
+			break
+		}
+	}
+	if best < 0 {
+		best = 2
+		for tau := 3; tau < maxTau; tau++ {
+			if cmnd[tau] < cmnd[best] {
+				best = tau
+			}
+		}
+		if cmnd[best] >= 1 {
+			return 0, 0, false
+		}
+	}
+
+	// parabolic interpolation around the dip, on the raw difference, as the normalization skews the
+	// shape of the dip for short periods
+	t := float64(best)
+	if best > 1 && best < maxTau {
+		y0, y1, y2 := diff[best-1], diff[best], diff[best+1]
+		if den := y0 - 2*y1 + y2; den != 0 {
+			t += 0.5 * (y0 - y2) / den
+		}
+	}
+
+	confidence := 1 - cmnd[best]
+	switch {
+	case confidence < 0:
+		confidence = 0
+	case confidence > 1:
+		confidence = 1
+	}
+	return t, confidence, true
+}
@@ -0,0 +1,105 @@
+package pcm
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/gotracker/voice/period"
+)
+
+// newToneFrames returns `length` frames of a sine wave at the frequency, at the sample rate
+func newToneFrames(length int, freq float64, sampleRate float64) []float64 {
+	return newSineFrames(length, sampleRate/freq, 0.5)
+}
+
+func TestDetectPitch(t *testing.T) {
+	tests := []struct {
+		name       string
+		freq       float64
+		sampleRate period.Frequency
+		wantNote   int
+		wantCents  float64
+		tolCents   float64
+	}{
+		{"A-440", 440, 44100, 69, 0, 1},
+		{"middle C", 261.6256, 44100, 60, 0, 1},
+		{"low E", 41.2034, 44100, 28, 0, 1},
+		{"25 cents sharp", 440 * math.Pow(2, 25.0/1200), 44100, 69, 25, 1},
+		{"30 cents flat", 220 * math.Pow(2, -30.0/1200), 44100, 57, -30, 1},
+		{"8363 Hz C-5", 261.6256, 8363, 60, 0, 1},
+		{"high", 3520, 22050, 105, 0, 5}, // a period of only 6.26 frames
+	}
+	for _, tc := range tests {
+		src := newSampleFromFrames([][]float64{newToneFrames(8192, tc.freq, float64(tc.sampleRate))}, 8192)
+		got, err := DetectPitch(src, tc.sampleRate, 0, 0)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if got.Note != tc.wantNote || math.Abs(got.Cents-tc.wantCents) > tc.tolCents {
+			t.Errorf("%s: got note %d %+.2f cents, want %d %+.2f cents", tc.name, got.Note, got.Cents, tc.wantNote, tc.wantCents)
+		}
+		if math.Abs(1200*math.Log2(float64(got.Frequency)/tc.freq)) > tc.tolCents {
+			t.Errorf("%s: got frequency %v, want %v", tc.name, got.Frequency, tc.freq)
+		}
+		if got.Confidence < 0.95 {
+			t.Errorf("%s: got confidence %v", tc.name, got.Confidence)
+		}
+	}
+}
+
+func TestDetectPitchRegion(t *testing.T) {
+	// 440 Hz then 220 Hz, in stereo with the right channel at half volume
+	left := append(newToneFrames(4096, 440, 44100), newToneFrames(4096, 220, 44100)...)
+	right := make([]float64, len(left))
+	for i, v := range left {
+		right[i] = v / 2
+	}
+	src := newSampleFromFrames([][]float64{left, right}, len(left))
+
+	for _, tc := range []struct {
+		begin, length int
+		wantNote      int
+	}{
+		{0, 4096, 69},
+		{4096, 4096, 57},
+		{4096, 0, 57},
+	} {
+		got, err := DetectPitch(src, 44100, tc.begin, tc.length)
+		if err != nil {
+			t.Errorf("%d+%d: %v", tc.begin, tc.length, err)
+			continue
+		}
+		if got.Note != tc.wantNote {
+			t.Errorf("%d+%d: got note %d, want %d", tc.begin, tc.length, got.Note, tc.wantNote)
+		}
+	}
+}
+
+func TestDetectPitchDefaultWindow(t *testing.T) {
+	// only the first 0.2 seconds are analysed when no length is given, so the change of tone
+	// after that is not seen
+	data := append(newToneFrames(8820, 440, 44100), newToneFrames(44100*10, 220, 44100)...)
+	src := newSampleFromFrames([][]float64{data}, len(data))
+	got, err := DetectPitch(src, 44100, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Note != 69 {
+		t.Errorf("got note %d, want 69", got.Note)
+	}
+}
+
+func TestDetectPitchErrors(t *testing.T) {
+	silence := newSampleFromFrames([][]float64{make([]float64, 4096)}, 4096)
+	if _, err := DetectPitch(silence, 44100, 0, 0); !errors.Is(err, ErrNoPitch) {
+		t.Errorf("silence: got %v, want %v", err, ErrNoPitch)
+	}
+	if _, err := DetectPitch(silence, 0, 0, 0); !errors.Is(err, ErrInvalidRate) {
+		t.Errorf("no rate: got %v, want %v", err, ErrInvalidRate)
+	}
+	if _, err := DetectPitch(silence, 44100, 0, 8192); err == nil {
+		t.Error("past the end: expected an error")
+	}
+}
@@ -0,0 +1,172 @@
+package pcm
+
+import (
+	"errors"
+	"math"
+
+	"github.com/gotracker/voice/loop"
+	"github.com/gotracker/voice/period"
+)
+
+// ResampleQuality is the quality of interpolation used when resampling
+type ResampleQuality uint8
+
+const (
+	// ResampleQualityLinear is for linear interpolation (fastest, lowest quality)
+	ResampleQualityLinear = ResampleQuality(iota)
+	// ResampleQualityCubic is for 4-point cubic hermite interpolation
+	ResampleQualityCubic
+	// ResampleQualitySinc is for windowed sinc interpolation with 16 taps
+	ResampleQualitySinc
+	// ResampleQualityBest is for windowed sinc interpolation with 64 taps
+	ResampleQualityBest
+)
+
+var (
+	// ErrInvalidRate is for when a sample rate is zero, negative, or not a number
+	ErrInvalidRate = errors.New("invalid sample rate")
+)
+
+// Resample converts the sample from the `fromRate` sample rate to the `toRate` sample rate,
+// returning a new sample in the requested format. The begin and end points of any supplied
+// loop settings are remapped to the new rate and returned in the same order.
+// The remapped points are rounded to whole samples, so a remapped loop can be up to a sample
+// longer or shorter than the exact one, which drifts the pitch of a short loop; use RemapLoop
+// for points that can make a loop.Fractional with the exact length instead.
+func Resample(from Sample, fromRate, toRate period.Frequency, quality ResampleQuality, format SampleDataFormat, loops ...loop.Settings) (Sample, []loop.Settings, error) {
+	if !(fromRate > 0) || !(toRate > 0) || math.IsInf(float64(fromRate), 0) || math.IsInf(float64(toRate), 0) {
+		return nil, nil, ErrInvalidRate
+	}
+
+	frames, err := readFrames(from)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ratio := float64(toRate) / float64(fromRate)
+	length := int(math.Round(float64(from.Length()) * ratio))
+	step := 1 / ratio
+
+	var interp func(data []float64, x float64) float64
+	switch quality {
+	case ResampleQualityLinear:
+		interp = interpLinear
+	case ResampleQualityCubic:
+		interp = interpCubic
+	case ResampleQualitySinc:
+		interp = newSincInterpolator(8, ratio)
+	case ResampleQualityBest:
+		interp = newSincInterpolator(32, ratio)
+	default:
+		return nil, nil, errors.New("unhandled resample quality")
+	}
+
+	out := make([][]float64, len(frames))
+	for c, data := range frames {
+		out[c] = make([]float64, length)
+		for i := range out[c] {
+			out[c][i] = interp(data, float64(i)*step)
+		}
+	}
+
+	var remapped []loop.Settings
+	for _, l := range loops {
+		fl := RemapLoop(l, fromRate, toRate)
+		remapped = append(remapped, loop.Settings{
+			Begin: int(math.Round(fl.Begin)),
+			End:   int(math.Round(fl.End)),
+		})
+	}
+
+	to, err := ConvertTo(newSampleFromFrames(out, length), format)
+	if err != nil {
+		return nil, nil, err
+	}
+	return to, remapped, nil
+}
+
+// RemapLoop returns the begin and end points of the loop settings, at the `fromRate` sample rate,
+// remapped to the `toRate` sample rate without rounding them to whole samples
+func RemapLoop(l loop.Settings, fromRate, toRate period.Frequency) loop.FractionalSettings {
+	ratio := float64(toRate) / float64(fromRate)
+	return loop.FractionalSettings{
+		Begin: float64(l.Begin) * ratio,
+		End:   float64(l.End) * ratio,
+	}
+}
+
+// frameAt returns the value at the position in the data, treating anything outside of it as silence
+func frameAt(data []float64, pos int) float64 {
+	if pos < 0 || pos >= len(data) {
+		return 0
+	}
+	return data[pos]
+}
+
+func interpLinear(data []float64, x float64) float64 {
+	i := math.Floor(x)
+	t := x - i
+	p := int(i)
+	y0 := frameAt(data, p)
+	if t == 0 {
+		return y0
+	}
+	y1 := frameAt(data, p+1)
+	return y0 + t*(y1-y0)
+}
+
+func interpCubic(data []float64, x float64) float64 {
+	i := math.Floor(x)
+	t := x - i
+	p := int(i)
+	ym1 := frameAt(data, p-1)
+	y0 := frameAt(data, p)
+	y1 := frameAt(data, p+1)
+	y2 := frameAt(data, p+2)
+
+	c1 := 0.5 * (y1 - ym1)
+	c2 := ym1 - 2.5*y0 + 2*y1 - 0.5*y2
+	c3 := 0.5*(y2-ym1) + 1.5*(y0-y1)
+	return ((c3*t+c2)*t+c1)*t + y0
+}
+
+// newSincInterpolator creates a blackman-windowed sinc interpolator with `halfTaps` zero crossings
+// on either side of the center. When downsampling, the cutoff is lowered to the new nyquist rate.
+func newSincInterpolator(halfTaps int, ratio float64) func(data []float64, x float64) float64 {
+	cutoff := 1.0
+	if ratio < 1 {
+		cutoff = ratio
+	}
+	width := float64(halfTaps) / cutoff
+
+	return func(data []float64, x float64) float64 {
+		first := int(math.Floor(x-width)) + 1
+		last := int(math.Floor(x + width))
+		var sum float64
+		for j := first; j <= last; j++ {
+			v := frameAt(data, j)
+			if v == 0 {
+				continue
+			}
+			t := x - float64(j)
+			sum += v * cutoff * sinc(cutoff*t) * blackman(t/width)
+		}
+		return sum
+	}
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	px := math.Pi * x
+	return math.Sin(px) / px
+}
+
+// blackman returns the blackman window value for `x` in the range [-1, 1]
+func blackman(x float64) float64 {
+	if x <= -1 || x >= 1 {
+		return 0
+	}
+	return 0.42 + 0.5*math.Cos(math.Pi*x) + 0.08*math.Cos(2*math.Pi*x)
+}
@@ -0,0 +1,183 @@
+package pcm
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/gotracker/voice/loop"
+	"github.com/gotracker/voice/period"
+)
+
+var allResampleQualities = []ResampleQuality{
+	ResampleQualityLinear,
+	ResampleQualityCubic,
+	ResampleQualitySinc,
+	ResampleQualityBest,
+}
+
+// newSineFrames returns `length` frames of a sine wave with a period of `period` frames
+func newSineFrames(length int, period float64, amp float64) []float64 {
+	out := make([]float64, length)
+	for i := range out {
+		out[i] = amp * math.Sin(2*math.Pi*float64(i)/period)
+	}
+	return out
+}
+
+func TestResampleLength(t *testing.T) {
+	tests := []struct {
+		fromRate, toRate period.Frequency
+		want             int
+	}{
+		{8000, 8000, 100},
+		{8000, 16000, 200},
+		{44100, 22050, 50},
+		{8363, 44100, 527}, // 527.32 frames
+		{44100, 8363, 19},  // 18.96 frames
+	}
+	src := newSampleFromFrames([][]float64{make([]float64, 100), make([]float64, 100)}, 100)
+	for _, tc := range tests {
+		for _, q := range allResampleQualities {
+			out, _, err := Resample(src, tc.fromRate, tc.toRate, q, SampleDataFormat16BitLESigned)
+			if err != nil {
+				t.Fatalf("%v->%v (%d): %v", tc.fromRate, tc.toRate, q, err)
+			}
+			if out.Length() != tc.want || out.Channels() != 2 {
+				t.Errorf("%v->%v (%d): got length %d, channels %d, want %d, 2", tc.fromRate, tc.toRate, q, out.Length(), out.Channels(), tc.want)
+			}
+		}
+	}
+}
+
+func TestResampleErrors(t *testing.T) {
+	src := newSampleFromFrames([][]float64{make([]float64, 10)}, 10)
+	for _, rates := range [][2]period.Frequency{
+		{0, 8000},
+		{8000, 0},
+		{-8000, 8000},
+		{period.Frequency(math.NaN()), 8000},
+		{8000, period.Frequency(math.Inf(1))},
+	} {
+		if _, _, err := Resample(src, rates[0], rates[1], ResampleQualityLinear, SampleDataFormat8BitSigned); !errors.Is(err, ErrInvalidRate) {
+			t.Errorf("%v: got %v, want %v", rates, err, ErrInvalidRate)
+		}
+	}
+	if _, _, err := Resample(src, 8000, 16000, ResampleQuality(99), SampleDataFormat8BitSigned); err == nil {
+		t.Error("unknown quality: expected an error")
+	}
+}
+
+func TestResampleLoops(t *testing.T) {
+	src := newSampleFromFrames([][]float64{make([]float64, 100)}, 100)
+	loops := []loop.Settings{
+		{Begin: 10, End: 37},
+		{Begin: 0, End: 100},
+	}
+	_, got, err := Resample(src, 8000, 12000, ResampleQualityLinear, SampleDataFormat8BitSigned, loops...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []loop.Settings{
+		{Begin: 15, End: 56}, // 55.5
+		{Begin: 0, End: 150},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("loop %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	// the exact points keep the length of the loop in time, where the rounded ones do not
+	fl := RemapLoop(loops[0], 8000, 12000)
+	if fl.Begin != 15 || fl.End != 55.5 {
+		t.Errorf("got %v, want {15 55.5}", fl)
+	}
+	fl = RemapLoop(loop.Settings{Begin: 3, End: 17}, 8363, 44100)
+	if gotLen, wantLen := fl.End-fl.Begin, 14*44100/8363.0; math.Abs(gotLen-wantLen) > 1e-9 {
+		t.Errorf("got loop length %v, want %v", gotLen, wantLen)
+	}
+}
+
+func TestResampleQuality(t *testing.T) {
+	src := newSampleFromFrames([][]float64{newSineFrames(512, 32, 0.5)}, 512)
+	want := newSineFrames(1024, 64, 0.5)
+
+	tests := []struct {
+		quality ResampleQuality
+		maxErr  float64
+	}{
+		{ResampleQualityLinear, 5e-3},
+		{ResampleQualityCubic, 5e-5},
+		{ResampleQualitySinc, 5e-6},
+		{ResampleQualityBest, 2e-6},
+	}
+	for _, tc := range tests {
+		// the same rate plays back the original frames
+		same, _, err := Resample(src, 8000, 8000, tc.quality, SampleDataFormat64BitLEFloat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		frames, _ := readFrames(same)
+		orig, _ := readFrames(src)
+		for i, v := range orig[0] {
+			if math.Abs(frames[0][i]-v) > 1e-12 {
+				t.Errorf("quality %d, same rate: frame %d is %v, want %v", tc.quality, i, frames[0][i], v)
+				break
+			}
+		}
+
+		// doubling the rate halves the frequency of the sine (relative to the new rate), with the
+		// error getting smaller as the quality goes up; the ends are skipped, as the
+		// interpolators see silence outside of the sample
+		up, _, err := Resample(src, 8000, 16000, tc.quality, SampleDataFormat64BitLEFloat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		frames, _ = readFrames(up)
+		var maxErr float64
+		for i := 128; i < len(want)-128; i++ {
+			maxErr = math.Max(maxErr, math.Abs(frames[0][i]-want[i]))
+		}
+		if maxErr > tc.maxErr {
+			t.Errorf("quality %d: got max error %v, want at most %v", tc.quality, maxErr, tc.maxErr)
+		}
+	}
+}
+
+func TestResampleSincLowPass(t *testing.T) {
+	// a tone above the nyquist rate of the new rate is filtered out by the sinc qualities,
+	// where a tone below it is kept
+	tests := []struct {
+		period  float64
+		quality ResampleQuality
+		minRMS  float64
+		maxRMS  float64
+	}{
+		{2.5, ResampleQualitySinc, 0, 0.05},
+		{2.5, ResampleQualityBest, 0, 0.02},
+		{16, ResampleQualitySinc, 0.34, 0.37},
+		{16, ResampleQualityBest, 0.34, 0.37},
+	}
+	for _, tc := range tests {
+		src := newSampleFromFrames([][]float64{newSineFrames(1024, tc.period, 0.5)}, 1024)
+		out, _, err := Resample(src, 16000, 8000, tc.quality, SampleDataFormat64BitLEFloat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		frames, _ := readFrames(out)
+		var sum float64
+		n := 0
+		for i := 128; i < len(frames[0])-128; i++ {
+			sum += frames[0][i] * frames[0][i]
+			n++
+		}
+		rms := math.Sqrt(sum / float64(n))
+		if rms < tc.minRMS || rms > tc.maxRMS {
+			t.Errorf("period %v, quality %d: got rms %v, want %v to %v", tc.period, tc.quality, rms, tc.minRMS, tc.maxRMS)
+		}
+	}
+}
@@ -5,8 +5,6 @@ import (
 	"encoding/binary"
 	"errors"
 	"math"
-
-	"github.com/gotracker/gomixing/volume"
 )
 
 // Sample is the interface to a sample
@@ -143,73 +141,75 @@ func NewSample(data []byte, length int, channels int, format SampleDataFormat) S
 	}
 }
 
-func ConvertTo(from Sample, format SampleDataFormat) (Sample, error) {
-	cvt := &bytes.Buffer{}
+// ConvertTo converts the sample to the requested sample data format, saturating values that
+// exceed the range of the format. When the conversion reduces the bit depth of the data, the
+// optional dither mode is applied (defaults to DitherModeNone).
+func ConvertTo(from Sample, format SampleDataFormat, dither ...DitherMode) (Sample, error) {
+	toBits, ok := formatBitDepth(format)
+	if !ok {
+		return nil, errors.New("unhandled format type")
+	}
+
 	length := from.Length()
 	channels := from.Channels()
+
+	mode := DitherModeNone
+	if len(dither) > 0 && toBits < sampleBitDepth(from) {
+		mode = dither[0]
+	}
+	d := newDitherer(mode, channels)
+
+	cvt := &bytes.Buffer{}
+	cvt.Grow(length * channels * toBits / 8)
+	from.Seek(0)
 	for i := 0; i < length; i++ {
-		samp, _ := from.Read() // ignore error
+		samp, err := from.Read()
+		if err != nil {
+			return nil, err
+		}
 		for c := 0; c < channels; c++ {
-			var vol volume.Volume
+			var vol float64
 			if samp.Channels > c {
-				vol = samp.StaticMatrix[c]
+				vol = float64(samp.StaticMatrix[c])
 			}
-			switch format {
-			case SampleDataFormat8BitUnsigned:
-				cv := (vol * 0x80) + 0x80
-				if err := binary.Write(cvt, binary.LittleEndian, uint8(cv)); err != nil {
-					return nil, err
-				}
-			case SampleDataFormat8BitSigned:
-				cv := (vol * 0x80)
-				if err := binary.Write(cvt, binary.LittleEndian, int8(cv)); err != nil {
-					return nil, err
-				}
-			case SampleDataFormat16BitLEUnsigned:
-				cv := (vol * 0x8000) + 0x8000
-				if err := binary.Write(cvt, binary.LittleEndian, uint16(cv)); err != nil {
-					return nil, err
-				}
-			case SampleDataFormat16BitLESigned:
-				cv := (vol * 0x8000)
-				if err := binary.Write(cvt, binary.LittleEndian, int16(cv)); err != nil {
-					return nil, err
-				}
-			case SampleDataFormat16BitBEUnsigned:
-				cv := (vol * 0x8000) + 0x8000
-				if err := binary.Write(cvt, binary.BigEndian, uint16(cv)); err != nil {
-					return nil, err
-				}
-			case SampleDataFormat16BitBESigned:
-				cv := (vol * 0x8000)
-				if err := binary.Write(cvt, binary.BigEndian, int16(cv)); err != nil {
-					return nil, err
-				}
-			case SampleDataFormat32BitLEFloat:
-				cv := vol
-				if err := binary.Write(cvt, binary.LittleEndian, math.Float32bits(float32(cv))); err != nil {
-					return nil, err
-				}
-			case SampleDataFormat32BitBEFloat:
-				cv := vol
-				if err := binary.Write(cvt, binary.BigEndian, math.Float32bits(float32(cv))); err != nil {
-					return nil, err
-				}
-			case SampleDataFormat64BitLEFloat:
-				cv := vol
-				if err := binary.Write(cvt, binary.LittleEndian, math.Float64bits(float64(cv))); err != nil {
-					return nil, err
-				}
-			case SampleDataFormat64BitBEFloat:
-				cv := vol
-				if err := binary.Write(cvt, binary.BigEndian, math.Float64bits(float64(cv))); err != nil {
-					return nil, err
-				}
-			default:
-				return nil, errors.New("unhandled format type")
+			if err := writeConverted(cvt, format, d, c, vol); err != nil {
+				return nil, err
 			}
 		}
 	}
 	to := NewSample(cvt.Bytes(), length, channels, format)
 	return to, nil
 }
+
+func writeConverted(cvt *bytes.Buffer, format SampleDataFormat, d *ditherer, c int, vol float64) error {
+	switch format {
+	case SampleDataFormat8BitUnsigned:
+		cv := d.quantize(c, vol, 0x80) + 0x80
+		return binary.Write(cvt, binary.LittleEndian, uint8(cv))
+	case SampleDataFormat8BitSigned:
+		cv := d.quantize(c, vol, 0x80)
+		return binary.Write(cvt, binary.LittleEndian, int8(cv))
+	case SampleDataFormat16BitLEUnsigned:
+		cv := d.quantize(c, vol, 0x8000) + 0x8000
+		return binary.Write(cvt, binary.LittleEndian, uint16(cv))
+	case SampleDataFormat16BitLESigned:
+		cv := d.quantize(c, vol, 0x8000)
+		return binary.Write(cvt, binary.LittleEndian, int16(cv))
+	case SampleDataFormat16BitBEUnsigned:
+		cv := d.quantize(c, vol, 0x8000) + 0x8000
+		return binary.Write(cvt, binary.BigEndian, uint16(cv))
+	case SampleDataFormat16BitBESigned:
+		cv := d.quantize(c, vol, 0x8000)
+		return binary.Write(cvt, binary.BigEndian, int16(cv))
+	case SampleDataFormat32BitLEFloat:
+		return binary.Write(cvt, binary.LittleEndian, math.Float32bits(float32(vol)))
+	case SampleDataFormat32BitBEFloat:
+		return binary.Write(cvt, binary.BigEndian, math.Float32bits(float32(vol)))
+	case SampleDataFormat64BitLEFloat:
+		return binary.Write(cvt, binary.LittleEndian, math.Float64bits(vol))
+	case SampleDataFormat64BitBEFloat:
+		return binary.Write(cvt, binary.BigEndian, math.Float64bits(vol))
+	default:
+		return errors.New("unhandled format type")
+	}
+}
@@ -0,0 +1,158 @@
+package pcm
+
+import (
+	"math"
+	"testing"
+)
+
+var allFormats = []SampleDataFormat{
+	SampleDataFormat8BitUnsigned,
+	SampleDataFormat8BitSigned,
+	SampleDataFormat16BitLEUnsigned,
+	SampleDataFormat16BitLESigned,
+	SampleDataFormat16BitBEUnsigned,
+	SampleDataFormat16BitBESigned,
+	SampleDataFormat32BitLEFloat,
+	SampleDataFormat32BitBEFloat,
+	SampleDataFormat64BitLEFloat,
+	SampleDataFormat64BitBEFloat,
+}
+
+// expectQuantized returns what storing the value in the format should read back as
+func expectQuantized(format SampleDataFormat, v float64) float64 {
+	var scale float64
+	switch bits, _ := formatBitDepth(format); bits {
+	case 8:
+		scale = 0x80
+	case 16:
+		scale = 0x8000
+	case 32:
+		return float64(float32(v))
+	default:
+		return v
+	}
+	return math.Max(-scale, math.Min(scale-1, math.Round(v*scale))) / scale
+}
+
+func TestConvertToRoundTrip(t *testing.T) {
+	values := []float64{-1.5, -1, -0.5, -1.0 / 3, 0, 0.25, 0.5, 0.999, 1, 1.5}
+	src := newSampleFromFrames([][]float64{values, values}, len(values))
+
+	for _, from := range allFormats {
+		for _, to := range allFormats {
+			a, err := ConvertTo(src, from)
+			if err != nil {
+				t.Fatalf("%d: %v", from, err)
+			}
+			b, err := ConvertTo(a, to)
+			if err != nil {
+				t.Fatalf("%d->%d: %v", from, to, err)
+			}
+			if b.Length() != len(values) || b.Channels() != 2 {
+				t.Fatalf("%d->%d: got length %d, channels %d", from, to, b.Length(), b.Channels())
+			}
+
+			frames, err := readFrames(b)
+			if err != nil {
+				t.Fatalf("%d->%d: %v", from, to, err)
+			}
+			for c := range frames {
+				for i, v := range values {
+					want := expectQuantized(to, expectQuantized(from, v))
+					if got := frames[c][i]; math.Abs(got-want) > 1e-9 {
+						t.Errorf("%d->%d: value %v channel %d: got %v, want %v", from, to, v, c, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestConvertToSaturates(t *testing.T) {
+	values := []float64{-4, -1, 1, 4}
+	src := newSampleFromFrames([][]float64{values}, len(values))
+
+	for _, to := range allFormats {
+		if bits, _ := formatBitDepth(to); bits > 16 {
+			continue
+		}
+		s, err := ConvertTo(src, to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		frames, err := readFrames(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lo, hi := expectQuantized(to, -1), expectQuantized(to, 1)
+		want := []float64{lo, lo, hi, hi}
+		for i := range values {
+			if frames[0][i] != want[i] {
+				t.Errorf("%d: value %v: got %v, want %v", to, values[i], frames[0][i], want[i])
+			}
+		}
+	}
+}
+
+func TestConvertToDither(t *testing.T) {
+	const length = 4096
+	values := make([]float64, length)
+	for i := range values {
+		values[i] = 1.2 * math.Sin(float64(i)*0.01)
+	}
+	src := newSampleFromFrames([][]float64{values}, length)
+
+	for _, mode := range []DitherMode{DitherModeTPDF, DitherModeNoiseShaped} {
+		for _, to := range []SampleDataFormat{SampleDataFormat8BitSigned, SampleDataFormat16BitLEUnsigned} {
+			a, err := ConvertTo(src, to, mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := ConvertTo(src, to, mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fa, _ := readFrames(a)
+			fb, _ := readFrames(b)
+
+			bits, _ := formatBitDepth(to)
+			lsb := math.Ldexp(1, 1-bits)
+			lo, hi := expectQuantized(to, -1), expectQuantized(to, 1)
+			var changed int
+			for i, v := range values {
+				got := fa[0][i]
+				if got != fb[0][i] {
+					t.Fatalf("mode %d format %d: dither is not deterministic at %d", mode, to, i)
+				}
+				if got < lo || got > hi {
+					t.Fatalf("mode %d format %d: %v is outside of [%v, %v]", mode, to, got, lo, hi)
+				}
+				want := expectQuantized(to, v)
+				if math.Abs(got-want) > 3*lsb {
+					t.Fatalf("mode %d format %d: value %v: got %v, want within 3 LSB of %v", mode, to, v, got, want)
+				}
+				if got != want {
+					changed++
+				}
+			}
+			if changed == 0 {
+				t.Errorf("mode %d format %d: dither made no difference", mode, to)
+			}
+		}
+	}
+
+	// dither only applies when the bit depth is reduced
+	narrow, err := ConvertTo(src, SampleDataFormat8BitSigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, _ := ConvertTo(narrow, SampleDataFormat16BitLESigned)
+	dithered, _ := ConvertTo(narrow, SampleDataFormat16BitLESigned, DitherModeTPDF)
+	fp, _ := readFrames(plain)
+	fd, _ := readFrames(dithered)
+	for i := range fp[0] {
+		if fp[0][i] != fd[0][i] {
+			t.Fatalf("widening conversion was dithered at %d", i)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package pcm
+
+import (
+	"github.com/gotracker/gomixing/volume"
+)
+
+// SampleView is a zero-copy view of a range of frames (and optionally a subset of channels)
+// of another sample
+type SampleView struct {
+	src      Sample
+	offset   int   // in multichannel samples
+	length   int   // in multichannel samples
+	channels []int // source channel for each view channel, or nil for all
+	pos      int
+}
+
+// NewSampleView constructs a view of `length` frames of the `src` sample, starting at frame `offset`.
+// If channels are provided, the view only presents those channels of the source, in the order given.
+func NewSampleView(src Sample, offset int, length int, channels ...int) (Sample, error) {
+	if offset < 0 || length < 0 || offset+length > src.Length() {
+		return nil, ErrIndexOutOfRange
+	}
+	for _, c := range channels {
+		if c < 0 || c >= src.Channels() {
+			return nil, ErrIndexOutOfRange
+		}
+	}
+
+	v := &SampleView{
+		src:    src,
+		offset: offset,
+		length: length,
+	}
+	if len(channels) > 0 {
+		v.channels = append([]int(nil), channels...)
+	}
+
+	// flatten views of views so reads only ever go one level deep
+	if sv, ok := src.(*SampleView); ok {
+		v.src = sv.src
+		v.offset += sv.offset
+		if sv.channels != nil {
+			if v.channels == nil {
+				v.channels = append([]int(nil), sv.channels...)
+			} else {
+				for i, c := range v.channels {
+					v.channels[i] = sv.channels[c]
+				}
+			}
+		}
+	}
+	return v, nil
+}
+
+// Source returns the sample that the view is based on
+func (v *SampleView) Source() Sample {
+	return v.src
+}
+
+// Offset returns the frame offset of the view within its source sample
+func (v *SampleView) Offset() int {
+	return v.offset
+}
+
+// Channels returns the channel count of the view
+func (v *SampleView) Channels() int {
+	if v.channels != nil {
+		return len(v.channels)
+	}
+	return v.src.Channels()
+}
+
+// Length returns the sample length of the view
+func (v *SampleView) Length() int {
+	return v.length
+}
+
+// Seek sets the current position in the view
+func (v *SampleView) Seek(pos int) {
+	v.pos = pos
+}
+
+// Tell returns the current position in the view
+func (v *SampleView) Tell() int {
+	return v.pos
+}
+
+// Read returns the next multichannel sample
+func (v *SampleView) Read() (volume.Matrix, error) {
+	if v.pos < 0 {
+		v.pos = 0
+	}
+
+	if v.pos >= v.length {
+		return volume.Matrix{}, ErrIndexOutOfRange
+	}
+
+	v.src.Seek(v.offset + v.pos)
+	samp, err := v.src.Read()
+	if err != nil {
+		return volume.Matrix{}, err
+	}
+	v.pos++
+
+	if v.channels == nil {
+		return samp, nil
+	}
+
+	out := volume.Matrix{
+		Channels: len(v.channels),
+	}
+	for i, c := range v.channels {
+		if c < samp.Channels {
+			out.StaticMatrix[i] = samp.StaticMatrix[c]
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,197 @@
+package pcm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// newViewSource returns a 3 channel sample of 10 frames, where each value is 100 times the
+// channel plus the frame
+func newViewSource() Sample {
+	frames := make([][]float64, 3)
+	for c := range frames {
+		frames[c] = make([]float64, 10)
+		for i := range frames[c] {
+			frames[c][i] = float64(c*100 + i)
+		}
+	}
+	return newSampleFromFrames(frames, 10)
+}
+
+// readViewValues returns the values of every frame of the sample, by channel
+func readViewValues(t *testing.T, s Sample) [][]float64 {
+	t.Helper()
+	out := make([][]float64, s.Channels())
+	s.Seek(0)
+	for i := 0; i < s.Length(); i++ {
+		m, err := s.Read()
+		if err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		if m.Channels != s.Channels() {
+			t.Fatalf("frame %d: got %d channels, want %d", i, m.Channels, s.Channels())
+		}
+		for c := range out {
+			out[c] = append(out[c], float64(m.StaticMatrix[c]))
+		}
+	}
+	if _, err := s.Read(); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("read past the end: got %v, want %v", err, ErrIndexOutOfRange)
+	}
+	return out
+}
+
+func TestSampleView(t *testing.T) {
+	tests := []struct {
+		name     string
+		offset   int
+		length   int
+		channels []int
+		want     [][]float64
+	}{
+		{
+			name:   "whole sample",
+			offset: 0, length: 10,
+			want: [][]float64{
+				{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+				{100, 101, 102, 103, 104, 105, 106, 107, 108, 109},
+				{200, 201, 202, 203, 204, 205, 206, 207, 208, 209},
+			},
+		},
+		{
+			name:   "range",
+			offset: 2, length: 5,
+			want: [][]float64{
+				{2, 3, 4, 5, 6},
+				{102, 103, 104, 105, 106},
+				{202, 203, 204, 205, 206},
+			},
+		},
+		{
+			name:   "range at the end",
+			offset: 7, length: 3,
+			want: [][]float64{
+				{7, 8, 9},
+				{107, 108, 109},
+				{207, 208, 209},
+			},
+		},
+		{
+			name:   "empty",
+			offset: 10, length: 0,
+			want: [][]float64{nil, nil, nil},
+		},
+		{
+			name:   "reordered channels",
+			offset: 4, length: 2, channels: []int{2, 0},
+			want: [][]float64{
+				{204, 205},
+				{4, 5},
+			},
+		},
+		{
+			name:   "repeated channel",
+			offset: 0, length: 2, channels: []int{1, 1},
+			want: [][]float64{
+				{100, 101},
+				{100, 101},
+			},
+		},
+	}
+	for _, tc := range tests {
+		v, err := NewSampleView(newViewSource(), tc.offset, tc.length, tc.channels...)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if v.Length() != tc.length || v.Channels() != len(tc.want) {
+			t.Errorf("%s: got length %d, channels %d, want %d, %d", tc.name, v.Length(), v.Channels(), tc.length, len(tc.want))
+			continue
+		}
+		if got := readViewValues(t, v); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSampleViewBounds(t *testing.T) {
+	src := newViewSource()
+	tests := []struct {
+		name           string
+		offset, length int
+		channels       []int
+	}{
+		{"negative offset", -1, 5, nil},
+		{"negative length", 0, -1, nil},
+		{"past the end", 8, 3, nil},
+		{"offset past the end", 11, 0, nil},
+		{"channel past the last", 0, 5, []int{0, 3}},
+		{"negative channel", 0, 5, []int{-1}},
+	}
+	for _, tc := range tests {
+		if _, err := NewSampleView(src, tc.offset, tc.length, tc.channels...); !errors.Is(err, ErrIndexOutOfRange) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, ErrIndexOutOfRange)
+		}
+	}
+}
+
+func TestSampleViewSeek(t *testing.T) {
+	v, err := NewSampleView(newViewSource(), 3, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Seek(2)
+	if m, err := v.Read(); err != nil || m.StaticMatrix[0] != 5 {
+		t.Errorf("seek 2: got %v, %v, want 5", m.StaticMatrix[0], err)
+	}
+	if v.Tell() != 3 {
+		t.Errorf("got position %d, want 3", v.Tell())
+	}
+	v.Seek(-2)
+	if m, err := v.Read(); err != nil || m.StaticMatrix[0] != 3 {
+		t.Errorf("seek -2: got %v, %v, want 3", m.StaticMatrix[0], err)
+	}
+	v.Seek(4)
+	if _, err := v.Read(); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("seek 4: got %v, want %v", err, ErrIndexOutOfRange)
+	}
+}
+
+func TestSampleViewOfView(t *testing.T) {
+	src := newViewSource()
+	inner, err := NewSampleView(src, 2, 6, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the view of a view reads straight from the source, with the offsets and channels combined
+	v, err := NewSampleView(inner, 1, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sv := v.(*SampleView)
+	if sv.Source() != src || sv.Offset() != 3 {
+		t.Errorf("got source %p, offset %d, want %p, 3", sv.Source(), sv.Offset(), src)
+	}
+	if got, want := readViewValues(t, v), [][]float64{{103, 104, 105}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// all of the inner view's channels
+	v, err = NewSampleView(inner, 4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readViewValues(t, v), [][]float64{{206, 207}, {106, 107}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// the bounds are those of the inner view, not the source
+	if _, err := NewSampleView(inner, 4, 3); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("past the end of the inner view: got %v, want %v", err, ErrIndexOutOfRange)
+	}
+	if _, err := NewSampleView(inner, 0, 1, 2); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("channel past the inner view's: got %v, want %v", err, ErrIndexOutOfRange)
+	}
+}
@@ -11,3 +11,9 @@ type PitchEnveloper interface {
 	GetCurrentPitchEnvelope() period.Delta
 	SetPitchEnvelopePosition(pos int)
 }
+
+// PitchEnvelopeRamper is an interface for voices that can provide the pitch envelope's value partway
+// through a tick, ramping from the previous tick's value to the current one
+type PitchEnvelopeRamper interface {
+	GetPitchEnvelopeAt(t float32) period.Delta
+}
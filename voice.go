@@ -23,6 +23,8 @@ type Voice interface {
 	//PanEnveloper
 	//PitchEnveloper
 	//FilterEnveloper
+	//EnvelopeCarrier
+	//ParamEnveloper
 
 	// == required function interfaces ==
 	Advance(tickDuration time.Duration)
@@ -174,6 +176,15 @@ func SetVolumeEnvelopePosition(v Voice, pos int) {
 	}
 }
 
+// GetVolumeEnvelopeAt returns the volume envelope's value at the fraction `t` (0 through 1) of the way
+// through the current tick, if the interface for it exists on the voice
+func GetVolumeEnvelopeAt(v Voice, t float32) volume.Volume {
+	if ve, ok := v.(VolumeEnvelopeRamper); ok {
+		return ve.GetVolumeEnvelopeAt(t)
+	}
+	return volume.Volume(1)
+}
+
 // == PanEnveloper ==
 
 // EnablePanEnvelope sets the pan envelope enable flag, if the interface for it exists on the voice
@@ -190,6 +201,15 @@ func SetPanEnvelopePosition(v Voice, pos int) {
 	}
 }
 
+// GetPanEnvelopeAt returns the pan envelope's value at the fraction `t` (0 through 1) of the way
+// through the current tick, if the interface for it exists on the voice
+func GetPanEnvelopeAt(v Voice, t float32) panning.Position {
+	if pe, ok := v.(PanEnvelopeRamper); ok {
+		return pe.GetPanEnvelopeAt(t)
+	}
+	return panning.CenterAhead
+}
+
 // == PitchEnveloper ==
 
 // EnablePitchEnvelope sets the pitch envelope enable flag, if the interface for it exists on the voice
@@ -206,6 +226,15 @@ func SetPitchEnvelopePosition(v Voice, pos int) {
 	}
 }
 
+// GetPitchEnvelopeAt returns the pitch envelope's value at the fraction `t` (0 through 1) of the way
+// through the current tick, if the interface for it exists on the voice
+func GetPitchEnvelopeAt(v Voice, t float32) period.Delta {
+	if pe, ok := v.(PitchEnvelopeRamper); ok {
+		return pe.GetPitchEnvelopeAt(t)
+	}
+	return period.Delta(0)
+}
+
 // == FilterEnveloper ==
 
 // EnableFilterEnvelope sets the filter envelope enable flag, if the interface for it exists on the voice
@@ -230,12 +259,87 @@ func GetCurrentFilterEnvelope(v Voice) int8 {
 	return 1
 }
 
+// GetFilterEnvelopeAt returns the filter envelope's value at the fraction `t` (0 through 1) of the way
+// through the current tick, if the interface for it exists on the voice
+func GetFilterEnvelopeAt(v Voice, t float32) int8 {
+	if pe, ok := v.(FilterEnvelopeRamper); ok {
+		return pe.GetFilterEnvelopeAt(t)
+	}
+	return 1
+}
+
+// == EnvelopeCarrier ==
+
+// CarryEnvelopes continues the envelopes of the voice from the envelope positions of the previous
+// voice `prev` (for those envelopes that have their carry flag set), if the interface for it exists on the voice
+func CarryEnvelopes(v Voice, prev Voice) {
+	if ec, ok := v.(EnvelopeCarrier); ok {
+		ec.CarryEnvelopes(prev)
+	}
+}
+
+// == ParamEnveloper ==
+
+// RegisterParamEnvelope adds an envelope driving an additional parameter under the name, if the
+// interface for it exists on the voice. It returns true if the envelope was registered.
+func RegisterParamEnvelope(v Voice, name string, env ParamEnvelope) bool {
+	if pe, ok := v.(ParamEnveloper); ok {
+		pe.RegisterParamEnvelope(name, env)
+		return true
+	}
+	return false
+}
+
+// UnregisterParamEnvelope removes the envelope registered under the name, if the interface for it exists on the voice
+func UnregisterParamEnvelope(v Voice, name string) {
+	if pe, ok := v.(ParamEnveloper); ok {
+		pe.UnregisterParamEnvelope(name)
+	}
+}
+
+// GetParamEnvelope returns the envelope registered under the name, if the interface for it exists on the voice
+func GetParamEnvelope(v Voice, name string) ParamEnvelope {
+	if pe, ok := v.(ParamEnveloper); ok {
+		return pe.GetParamEnvelope(name)
+	}
+	return nil
+}
+
+// GetParamEnvelopeValue returns the current value of the envelope registered under the name, if the
+// interface for it exists on the voice and the envelope provides a value of type V. It returns false otherwise.
+func GetParamEnvelopeValue[V any](v Voice, name string) (V, bool) {
+	if env, ok := GetParamEnvelope(v, name).(ParamEnvelopeValue[V]); ok {
+		return env.GetCurrentValue(), true
+	}
+	var empty V
+	return empty, false
+}
+
+// SetParamEnvelopePosition sets the position of the envelope registered under the name, if the interface for it exists on the voice
+func SetParamEnvelopePosition(v Voice, name string, pos int) {
+	if env := GetParamEnvelope(v, name); env != nil {
+		if cb := env.SetEnvelopePosition(pos); cb != nil {
+			cb(v)
+		}
+	}
+}
+
 // == Envelopes ==
 
 // SetEnvelopePosition sets the envelope position(s) on the voice
+// FT2's Lxx only sets the panning envelope's position when the volume envelope has its sustain
+// flag set (a replayer bug that ft2clone and OpenMPT reproduce). That is not modelled here, as the
+// voice does not expose its volume envelope's settings: a player that wants it should call
+// SetVolumeEnvelopePosition and, only when the instrument's volume envelope has a sustain point,
+// SetPanEnvelopePosition instead.
 func SetAllEnvelopePositions(v Voice, pos int) {
 	SetVolumeEnvelopePosition(v, pos)
 	SetPanEnvelopePosition(v, pos)
 	SetPitchEnvelopePosition(v, pos)
 	SetFilterEnvelopePosition(v, pos)
+	if pe, ok := v.(ParamEnveloper); ok {
+		for _, name := range pe.GetParamEnvelopeNames() {
+			SetParamEnvelopePosition(v, name, pos)
+		}
+	}
 }
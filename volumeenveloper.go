@@ -11,3 +11,9 @@ type VolumeEnveloper interface {
 	GetCurrentVolumeEnvelope() volume.Volume
 	SetVolumeEnvelopePosition(pos int)
 }
+
+// VolumeEnvelopeRamper is an interface for voices that can provide the volume envelope's value partway
+// through a tick, ramping from the previous tick's value to the current one
+type VolumeEnvelopeRamper interface {
+	GetVolumeEnvelopeAt(t float32) volume.Volume
+}